@@ -0,0 +1,152 @@
+package apachethrift
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+)
+
+// Processor 使用 Apache Thrift 的 TProtocol 读取一条调用消息，执行 Kitex endpoint 并写回结果。
+// kitex_gen 生成的 Args/Result 实现了 thrift.TStruct，因此可以直接用 Apache 的协议实现读写，
+// 用于 Kitex 编解码器不支持的 compact、non-strict binary 与 THeader 等格式。
+type Processor struct {
+	svcInfo     *serviceinfo.ServiceInfo
+	svcSearcher remote.ServiceSearcher
+	endpoint    endpoint.Endpoint
+}
+
+// NewProcessor 创建 Processor，endpoint 为服务端的调用链（即 SetInvokeHandleFunc 注入的 endpoint）
+func NewProcessor(svcInfo *serviceinfo.ServiceInfo, svcSearcher remote.ServiceSearcher, ep endpoint.Endpoint) *Processor {
+	return &Processor{svcInfo: svcInfo, svcSearcher: svcSearcher, endpoint: ep}
+}
+
+// Process 处理一条调用消息。ctx 中需要已有 RPCInfo；
+// 返回的 error 表示连接级错误（读写失败），调用方应关闭连接，业务错误会以 TApplicationException 写回
+func (p *Processor) Process(ctx context.Context, iprot, oprot thrift.TProtocol) error {
+	name, typeID, seqID, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return err
+	}
+	if typeID != thrift.CALL && typeID != thrift.ONEWAY {
+		return fmt.Errorf("apachethrift: unexpected message type %d", typeID)
+	}
+
+	svcInfo := p.searchService(name)
+	var mtInfo serviceinfo.MethodInfo
+	if svcInfo != nil {
+		mtInfo = svcInfo.MethodInfo(name)
+	}
+	if mtInfo == nil {
+		if err := skipMessage(iprot); err != nil {
+			return err
+		}
+		exc := thrift.NewTApplicationException(thrift.UNKNOWN_METHOD, "unknown method "+name)
+		return writeException(ctx, oprot, name, seqID, exc)
+	}
+
+	args, ok := mtInfo.NewArgs().(thrift.TStruct)
+	if !ok {
+		return fmt.Errorf("apachethrift: args of %s do not implement thrift.TStruct", name)
+	}
+	if err := args.Read(iprot); err != nil {
+		exc := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+		_ = writeException(ctx, oprot, name, seqID, exc)
+		return err
+	}
+	if err := iprot.ReadMessageEnd(); err != nil {
+		return err
+	}
+
+	// 填充 RPCInfo，服务端 endpoint 依赖 Invocation 中的服务名和方法名进行分发
+	ri := rpcinfo.GetRPCInfo(ctx)
+	if ri != nil {
+		if setter, ok := ri.Invocation().(rpcinfo.InvocationSetter); ok {
+			setter.SetPackageName(svcInfo.GetPackageName())
+			setter.SetServiceName(svcInfo.ServiceName)
+			setter.SetMethodName(name)
+			setter.SetSeqID(seqID)
+		}
+		if to := rpcinfo.AsMutableEndpointInfo(ri.To()); to != nil {
+			_ = to.SetMethod(name)
+		}
+	}
+
+	result := mtInfo.NewResult()
+	callErr := p.endpoint(ctx, args, result)
+	if typeID == thrift.ONEWAY || mtInfo.OneWay() {
+		return nil
+	}
+	// handler 返回的业务错误由 Kitex 记录在 Invocation 中，endpoint 本身返回 nil
+	if callErr == nil && ri != nil {
+		if bizErr := ri.Invocation().BizStatusErr(); bizErr != nil {
+			callErr = bizErr
+		}
+	}
+	if callErr != nil {
+		return writeException(ctx, oprot, name, seqID, toApplicationException(callErr))
+	}
+	res, ok := result.(thrift.TStruct)
+	if !ok {
+		return fmt.Errorf("apachethrift: result of %s does not implement thrift.TStruct", name)
+	}
+	if err := oprot.WriteMessageBegin(name, thrift.REPLY, seqID); err != nil {
+		return err
+	}
+	if err := res.Write(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return err
+	}
+	return oprot.Flush(ctx)
+}
+
+// searchService 按方法名查找服务，多服务场景下 Apache 客户端不携带服务名，只能按方法名匹配
+func (p *Processor) searchService(method string) *serviceinfo.ServiceInfo {
+	if p.svcSearcher != nil {
+		if svc := p.svcSearcher.SearchService("", method, false); svc != nil {
+			return svc
+		}
+	}
+	return p.svcInfo
+}
+
+func skipMessage(iprot thrift.TProtocol) error {
+	if err := iprot.Skip(thrift.STRUCT); err != nil {
+		return err
+	}
+	return iprot.ReadMessageEnd()
+}
+
+func writeException(ctx context.Context, oprot thrift.TProtocol, name string, seqID int32, exc thrift.TApplicationException) error {
+	if err := oprot.WriteMessageBegin(name, thrift.EXCEPTION, seqID); err != nil {
+		return err
+	}
+	if err := exc.Write(oprot); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return err
+	}
+	return oprot.Flush(ctx)
+}
+
+// toApplicationException 把 endpoint 返回的错误转换为 TApplicationException
+func toApplicationException(err error) thrift.TApplicationException {
+	var exc thrift.TApplicationException
+	if errors.As(err, &exc) {
+		return exc
+	}
+	if bizErr, ok := kerrors.FromBizStatusError(err); ok {
+		return thrift.NewTApplicationException(thrift.INTERNAL_ERROR,
+			fmt.Sprintf("biz error: code=%d, msg=%s", bizErr.BizStatusCode(), bizErr.BizMessage()))
+	}
+	return thrift.NewTApplicationException(thrift.INTERNAL_ERROR, err.Error())
+}
@@ -0,0 +1,100 @@
+package apachethrift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability/stservice"
+)
+
+func echoEndpoint(ctx context.Context, req, resp interface{}) error {
+	args := req.(*stability.STServiceTestSTReqArgs)
+	resp.(*stability.STServiceTestSTReqResult).Success = &stability.STResponse{Name: args.Req.Name}
+	return nil
+}
+
+func TestProcessor_Compact(t *testing.T) {
+	p := NewProcessor(stservice.NewServiceInfo(), nil, echoEndpoint)
+
+	in := thrift.NewTMemoryBuffer()
+	cp := thrift.NewTCompactProtocol(in)
+	name := "bob"
+	assert.NoError(t, cp.WriteMessageBegin("testSTReq", thrift.CALL, 7))
+	assert.NoError(t, (&stability.STServiceTestSTReqArgs{Req: &stability.STRequest{Name: &name}}).Write(cp))
+	assert.NoError(t, cp.WriteMessageEnd())
+
+	trans := &messageTransport{r: in}
+	prot := thrift.NewTCompactProtocol(trans)
+	assert.NoError(t, p.Process(context.Background(), prot, prot))
+
+	out := thrift.NewTCompactProtocol(&messageTransport{r: &trans.out})
+	method, typeID, seqID, err := out.ReadMessageBegin()
+	assert.NoError(t, err)
+	assert.Equal(t, "testSTReq", method)
+	assert.Equal(t, thrift.REPLY, typeID)
+	assert.Equal(t, int32(7), seqID)
+	result := stability.NewSTServiceTestSTReqResult()
+	assert.NoError(t, result.Read(out))
+	assert.Equal(t, "bob", result.Success.GetName())
+}
+
+func TestProcessor_UnknownMethod(t *testing.T) {
+	p := NewProcessor(stservice.NewServiceInfo(), nil, echoEndpoint)
+
+	in := thrift.NewTMemoryBuffer()
+	bp := thrift.NewTBinaryProtocol(in, false, false)
+	assert.NoError(t, bp.WriteMessageBegin("missing", thrift.CALL, 1))
+	assert.NoError(t, (&stability.STServiceTestSTReqArgs{}).Write(bp))
+	assert.NoError(t, bp.WriteMessageEnd())
+
+	trans := &messageTransport{r: in}
+	prot := thrift.NewTBinaryProtocol(trans, false, false)
+	assert.NoError(t, p.Process(context.Background(), prot, prot))
+
+	out := thrift.NewTBinaryProtocol(&messageTransport{r: &trans.out}, false, false)
+	_, typeID, _, err := out.ReadMessageBegin()
+	assert.NoError(t, err)
+	assert.Equal(t, thrift.EXCEPTION, typeID)
+	exc := thrift.NewTApplicationException(0, "")
+	err = exc.Read(out)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(thrift.UNKNOWN_METHOD), exc.TypeId())
+}
+
+func TestProcessor_BizError(t *testing.T) {
+	// 与 Kitex 服务端一致：业务错误写入 Invocation，endpoint 返回 nil
+	bizEndpoint := func(ctx context.Context, req, resp interface{}) error {
+		setter := rpcinfo.GetRPCInfo(ctx).Invocation().(rpcinfo.InvocationSetter)
+		setter.SetBizStatusErr(kerrors.NewBizStatusError(1001, "boom"))
+		return nil
+	}
+	p := NewProcessor(stservice.NewServiceInfo(), nil, bizEndpoint)
+
+	in := thrift.NewTMemoryBuffer()
+	bp := thrift.NewTBinaryProtocol(in, false, true)
+	assert.NoError(t, bp.WriteMessageBegin("testSTReq", thrift.CALL, 3))
+	assert.NoError(t, (&stability.STServiceTestSTReqArgs{Req: &stability.STRequest{}}).Write(bp))
+	assert.NoError(t, bp.WriteMessageEnd())
+
+	ri := rpcinfo.NewRPCInfo(nil, nil, rpcinfo.NewServerInvocation(), rpcinfo.NewRPCConfig(), rpcinfo.NewRPCStats())
+	ctx := rpcinfo.NewCtxWithRPCInfo(context.Background(), ri)
+	trans := &messageTransport{r: in}
+	prot := thrift.NewTBinaryProtocol(trans, false, true)
+	assert.NoError(t, p.Process(ctx, prot, prot))
+
+	out := thrift.NewTBinaryProtocol(&messageTransport{r: &trans.out}, false, true)
+	_, typeID, seqID, err := out.ReadMessageBegin()
+	assert.NoError(t, err)
+	assert.Equal(t, thrift.EXCEPTION, typeID)
+	assert.Equal(t, int32(3), seqID)
+	exc := thrift.NewTApplicationException(0, "")
+	assert.NoError(t, exc.Read(out))
+	assert.Equal(t, int32(thrift.INTERNAL_ERROR), exc.TypeId())
+	assert.Equal(t, "biz error: code=1001, msg=boom", exc.Error())
+}
@@ -0,0 +1,271 @@
+package apachethrift
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/transport"
+	"github.com/cloudwego/netpoll"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// 单帧大小上限，与 Apache Thrift 的 TFramedTransport 默认值一致
+const maxFrameSize = 16384000
+
+var errNotSupported = errors.New("apachethrift: Read/Write are not used, messages are processed in OnRead")
+
+type svrTransHandlerFactory struct{}
+
+// NewSvrTransHandlerFactory 创建处理 Apache Thrift 连接的 handler 工厂，
+// 连接的变体取自 ctx 中的 protocol.ThriftVariant，缺失时在 OnActive 中自行识别
+func NewSvrTransHandlerFactory() remote.ServerTransHandlerFactory {
+	return &svrTransHandlerFactory{}
+}
+
+func (f *svrTransHandlerFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	return &svrTransHandler{opt: opt}, nil
+}
+
+type svrTransHandler struct {
+	opt       *remote.ServerOption
+	transPipe *remote.TransPipeline
+	processor *Processor
+}
+
+func (h *svrTransHandler) OnActive(ctx context.Context, conn net.Conn) (context.Context, error) {
+	if protocol.ThriftVariantFromContext(ctx) != protocol.ThriftVariantUnknown {
+		return ctx, nil
+	}
+	peek, err := protocol.PeekConn(conn, protocol.ThriftPeekSize)
+	if err != nil {
+		return ctx, err
+	}
+	return protocol.WithThriftVariant(ctx, protocol.ClassifyThrift(peek)), nil
+}
+
+// OnRead 依次处理连接缓冲区中的所有消息，出错时返回 error 由框架关闭连接
+func (h *svrTransHandler) OnRead(ctx context.Context, conn net.Conn) error {
+	reader, ok := protocol.ConnReader(conn)
+	if !ok {
+		return fmt.Errorf("apachethrift: unsupported connection type %T", conn)
+	}
+	variant := protocol.ThriftVariantFromContext(ctx)
+	for {
+		if err := h.serveMessage(ctx, conn, reader, variant); err != nil {
+			return err
+		}
+		if reader.Len() == 0 {
+			return nil
+		}
+	}
+}
+
+func (h *svrTransHandler) serveMessage(ctx context.Context, conn net.Conn, reader netpoll.Reader, variant protocol.ThriftVariant) error {
+	defer reader.Release()
+
+	trans, tp, err := readMessage(reader, variant)
+	if err != nil {
+		return err
+	}
+	iprot, oprot, err := newProtocols(variant, trans)
+	if err != nil {
+		return err
+	}
+
+	ri := h.opt.InitOrResetRPCInfoFunc(nil, conn.RemoteAddr())
+	if cfg := rpcinfo.AsMutableRPCConfig(ri.Config()); cfg != nil {
+		cfg.SetTransportProtocol(tp)
+	}
+	ctx = rpcinfo.NewCtxWithRPCInfo(ctx, ri)
+	if err := h.processor.Process(ctx, iprot, oprot); err != nil {
+		return err
+	}
+	if trans.out.Len() == 0 {
+		// oneway 调用没有响应
+		return nil
+	}
+	return writeMessage(conn, trans, tp, variant)
+}
+
+// Refuse 读取连接上的第一条消息，不调用业务逻辑，直接以 exc 作为该调用的 TApplicationException 回复，
+// 用于按访问策略拒绝 Thrift 客户端。无法解析的变体（如 Kitex TTHeader）返回错误，由调用方关闭连接
+func Refuse(ctx context.Context, conn net.Conn, exc thrift.TApplicationException) error {
+	reader, ok := protocol.ConnReader(conn)
+	if !ok {
+		return fmt.Errorf("apachethrift: unsupported connection type %T", conn)
+	}
+	defer reader.Release()
+	peek, err := reader.Peek(protocol.ThriftPeekSize)
+	if err != nil {
+		return err
+	}
+	variant := protocol.ClassifyThrift(peek)
+	trans, tp, err := readMessage(reader, variant)
+	if err != nil {
+		return err
+	}
+	iprot, oprot, err := newProtocols(variant, trans)
+	if err != nil {
+		return err
+	}
+	name, _, seqID, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return err
+	}
+	if err := writeException(ctx, oprot, name, seqID, exc); err != nil {
+		return err
+	}
+	return writeMessage(conn, trans, tp, variant)
+}
+
+// readMessage 按变体准备读取一条消息的传输层：分帧的变体先读出完整帧，其它变体直接从 reader 读取
+func readMessage(reader netpoll.Reader, variant protocol.ThriftVariant) (*messageTransport, transport.Protocol, error) {
+	trans := &messageTransport{}
+	switch variant {
+	case protocol.ThriftFramed, protocol.ThriftFramedCompact:
+		frame, err := readFrame(reader)
+		if err != nil {
+			return nil, 0, err
+		}
+		trans.r = bytes.NewReader(frame[4:])
+		return trans, transport.Framed, nil
+	case protocol.ThriftTHeader:
+		// THeaderTransport 自己处理帧头，需要包含长度前缀的完整帧
+		frame, err := readFrame(reader)
+		if err != nil {
+			return nil, 0, err
+		}
+		trans.r = bytes.NewReader(frame)
+		return trans, transport.Framed, nil
+	}
+	trans.r = &netpollReader{reader: reader}
+	return trans, transport.PurePayload, nil
+}
+
+// writeMessage 把 trans 中缓存的响应写回连接，分帧的变体补上长度前缀
+func writeMessage(conn net.Conn, trans *messageTransport, tp transport.Protocol, variant protocol.ThriftVariant) error {
+	out := trans.out.Bytes()
+	if tp == transport.Framed && variant != protocol.ThriftTHeader {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(out)))
+		out = append(size[:], out...)
+	}
+	c, ok := conn.(netpoll.Connection)
+	if !ok {
+		_, err := conn.Write(out)
+		return err
+	}
+	w := c.Writer()
+	if _, err := w.WriteBinary(out); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// newProtocols 按变体创建读写协议，THeader 的读写共用同一个协议以沿用请求的编码方式
+func newProtocols(variant protocol.ThriftVariant, trans thrift.TTransport) (iprot, oprot thrift.TProtocol, err error) {
+	switch variant {
+	case protocol.ThriftCompact, protocol.ThriftFramedCompact:
+		p := thrift.NewTCompactProtocol(trans)
+		return p, p, nil
+	case protocol.ThriftNonStrict:
+		p := thrift.NewTBinaryProtocol(trans, false, false)
+		return p, p, nil
+	case protocol.ThriftBuffered, protocol.ThriftFramed:
+		p := thrift.NewTBinaryProtocol(trans, true, true)
+		return p, p, nil
+	case protocol.ThriftTHeader:
+		p := thrift.NewTHeaderProtocol(trans)
+		return p, p, nil
+	}
+	return nil, nil, fmt.Errorf("apachethrift: unsupported thrift variant %s", variant)
+}
+
+// readFrame 读取 4 字节长度前缀及其后的帧内容，返回的数据包含长度前缀
+func readFrame(reader netpoll.Reader) ([]byte, error) {
+	hdr, err := reader.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(hdr)
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("apachethrift: frame size %d exceeds the limit %d", size, maxFrameSize)
+	}
+	return reader.ReadBinary(4 + int(size))
+}
+
+func (h *svrTransHandler) Read(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return ctx, errNotSupported
+}
+
+func (h *svrTransHandler) Write(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return ctx, errNotSupported
+}
+
+func (h *svrTransHandler) OnInactive(ctx context.Context, conn net.Conn) {}
+
+func (h *svrTransHandler) OnError(ctx context.Context, err error, conn net.Conn) {
+	klog.CtxErrorf(ctx, "KITEX: apache thrift processing error, remote=%s, error=%v", conn.RemoteAddr(), err)
+}
+
+func (h *svrTransHandler) OnMessage(ctx context.Context, args, result remote.Message) (context.Context, error) {
+	return ctx, errNotSupported
+}
+
+func (h *svrTransHandler) SetPipeline(pipeline *remote.TransPipeline) {
+	h.transPipe = pipeline
+}
+
+func (h *svrTransHandler) SetInvokeHandleFunc(inkHdlFunc endpoint.Endpoint) {
+	h.processor = NewProcessor(h.opt.TargetSvcInfo, h.opt.SvcSearcher, inkHdlFunc)
+}
+
+// messageTransport 是单条消息的 TTransport：从 r 读取请求，响应写入内存缓冲区
+type messageTransport struct {
+	r   io.Reader
+	out bytes.Buffer
+}
+
+func (t *messageTransport) Read(p []byte) (int, error)        { return t.r.Read(p) }
+func (t *messageTransport) Write(p []byte) (int, error)       { return t.out.Write(p) }
+func (t *messageTransport) Close() error                      { return nil }
+func (t *messageTransport) Flush(ctx context.Context) error   { return nil }
+func (t *messageTransport) RemainingBytes() (numBytes uint64) { return ^uint64(0) }
+func (t *messageTransport) Open() error                       { return nil }
+func (t *messageTransport) IsOpen() bool                      { return true }
+
+// netpollReader 以 io.Reader 的方式从 netpoll 缓冲区读取，只读取调用方需要的字节，
+// 不会像 bufio 那样预读后续消息
+type netpollReader struct {
+	reader netpoll.Reader
+}
+
+func (r *netpollReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n := r.reader.Len()
+	if n == 0 {
+		// 缓冲区为空时阻塞等待至少 1 个字节
+		n = 1
+	}
+	if n > len(p) {
+		n = len(p)
+	}
+	b, err := r.reader.Next(n)
+	if err != nil {
+		return 0, err
+	}
+	return copy(p, b), nil
+}
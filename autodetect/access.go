@@ -0,0 +1,151 @@
+package autodetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/codes"
+	"golang.org/x/net/http2"
+
+	"github.com/BeroKiTeer/KitBridge/apachethrift"
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// AccessRule 把协议限制在指定的监听地址与客户端网段上。
+// 协议出现在任意一条规则中时，只接受至少匹配其中一条规则的连接；未出现在规则中的协议不受限制
+type AccessRule struct {
+	Protocols []protocol.ProtocolType
+	// Listeners 是连接的本端地址 "ip:port"，或 ":port" 表示任意 IP，为空时匹配任意地址。
+	// 经过 PROXY 头时为负载均衡器接收连接的地址
+	Listeners []string
+	// Sources 是客户端网段，为空时匹配任意来源；经过 PROXY 头时为真实客户端地址
+	Sources []*net.IPNet
+}
+
+// OutcomeDenied 是被访问规则拒绝的连接在 DetectionStats 中的计数键
+const OutcomeDenied = "denied"
+
+// ErrProtocolDenied 表示连接使用的协议不被访问规则允许
+var ErrProtocolDenied = errors.New("autodetect: protocol not allowed")
+
+var http403Response = []byte("HTTP/1.1 403 Forbidden\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Length: 10\r\n" +
+	"Connection: close\r\n\r\n" +
+	"forbidden\n")
+
+type accessRules []AccessRule
+
+func (rules accessRules) allowed(p protocol.ProtocolType, conn net.Conn) bool {
+	restricted := false
+	for _, r := range rules {
+		if !r.covers(p) {
+			continue
+		}
+		restricted = true
+		if r.matchListener(conn.LocalAddr()) && r.matchSource(conn.RemoteAddr()) {
+			return true
+		}
+	}
+	return !restricted
+}
+
+func (r *AccessRule) covers(p protocol.ProtocolType) bool {
+	for _, rp := range r.Protocols {
+		if rp == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *AccessRule) matchListener(addr net.Addr) bool {
+	if len(r.Listeners) == 0 {
+		return true
+	}
+	ip := addrIP(addr)
+	_, port, _ := net.SplitHostPort(addr.String())
+	for _, l := range r.Listeners {
+		host, p, err := net.SplitHostPort(l)
+		if err != nil || p != port {
+			continue
+		}
+		if host == "" || net.ParseIP(host).Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *AccessRule) matchSource(addr net.Addr) bool {
+	if len(r.Sources) == 0 {
+		return true
+	}
+	ip := addrIP(addr)
+	for _, n := range r.Sources {
+		if ip != nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// admit 决定嗅探后的连接能否继续：未识别的连接先按 UnknownAction 处理，交给 Thrift 兜底时按 Thrift 检查访问规则；
+// 被拒绝的连接收到与协议对应的拒绝回复，返回的 error 使连接被关闭
+func (p sniffPolicy) admit(ctx context.Context, conn net.Conn, res sniffResult, rwTimeout time.Duration) error {
+	proto := res.protocol
+	if proto == protocol.ProtocolUnknown {
+		if err := p.reject(conn, res); err != nil {
+			return err
+		}
+		proto = protocol.ProtocolThrift
+	}
+	if p.access.allowed(proto, conn) {
+		return nil
+	}
+	countOutcome(OutcomeDenied)
+	restore := setSniffDeadline(conn, p.timeout, rwTimeout)
+	refuse(ctx, conn, proto)
+	restore()
+	return fmt.Errorf("%w: %s from %s", ErrProtocolDenied, proto, conn.RemoteAddr())
+}
+
+// refuse 以协议对应的方式拒绝连接：HTTP/1 回复 403，h2c 与 gRPC 回复 403 / PERMISSION_DENIED，
+// Thrift 以 TApplicationException 回复第一个调用，TLS、PROXY 等无法回复的协议直接关闭
+func refuse(ctx context.Context, conn net.Conn, p protocol.ProtocolType) {
+	var err error
+	switch p {
+	case protocol.ProtocolHttp:
+		_, err = conn.Write(http403Response)
+	case protocol.ProtocolHttp2:
+		// 连接的读超时使客户端空闲后 ServeConn 返回
+		(&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Context: ctx, Handler: http.HandlerFunc(forbidden)})
+	case protocol.ProtocolThrift:
+		exc := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION,
+			fmt.Sprintf("thrift is not allowed from %s", conn.RemoteAddr()))
+		err = apachethrift.Refuse(ctx, conn, exc)
+	}
+	if err != nil {
+		klog.CtxDebugf(ctx, "KITEX: refuse %s connection failed, remote=%s, error=%v", p, conn.RemoteAddr(), err)
+	}
+}
+
+func forbidden(w http.ResponseWriter, r *http.Request) {
+	if ct := r.Header.Get("Content-Type"); strings.HasPrefix(ct, "application/grpc") {
+		// trailers-only 响应
+		w.Header().Set("Content-Type", ct)
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(codes.PermissionDenied)))
+		w.Header().Set("Grpc-Message", "forbidden")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Error(w, "forbidden", http.StatusForbidden)
+}
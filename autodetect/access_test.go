@@ -0,0 +1,102 @@
+package autodetect
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+type addrConn struct {
+	net.Conn
+	local, remote net.Addr
+}
+
+func (c *addrConn) LocalAddr() net.Addr  { return c.local }
+func (c *addrConn) RemoteAddr() net.Addr { return c.remote }
+
+func TestAccessRules(t *testing.T) {
+	_, internal, _ := net.ParseCIDR("10.0.0.0/8")
+	rules := accessRules{
+		{Protocols: []protocol.ProtocolType{protocol.ProtocolThrift}, Sources: []*net.IPNet{internal}},
+		{Protocols: []protocol.ProtocolType{protocol.ProtocolThrift}, Listeners: []string{"127.0.0.1:9000"}},
+		{Protocols: []protocol.ProtocolType{protocol.ProtocolHttp2}, Listeners: []string{":8888"}},
+	}
+	conn := func(local, remote string) net.Conn {
+		l, _ := net.ResolveTCPAddr("tcp", local)
+		r, _ := net.ResolveTCPAddr("tcp", remote)
+		return &addrConn{local: l, remote: r}
+	}
+	cases := []struct {
+		p      protocol.ProtocolType
+		conn   net.Conn
+		expect bool
+	}{
+		{protocol.ProtocolThrift, conn("1.2.3.4:8888", "10.1.2.3:5000"), true},
+		{protocol.ProtocolThrift, conn("1.2.3.4:8888", "8.8.8.8:5000"), false},
+		{protocol.ProtocolThrift, conn("127.0.0.1:9000", "8.8.8.8:5000"), true},
+		{protocol.ProtocolHttp, conn("1.2.3.4:8888", "8.8.8.8:5000"), true},
+		{protocol.ProtocolHttp2, conn("1.2.3.4:8888", "8.8.8.8:5000"), true},
+		{protocol.ProtocolHttp2, conn("1.2.3.4:9999", "8.8.8.8:5000"), false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expect, rules.allowed(c.p, c.conn), "%s %s->%s", c.p, c.conn.RemoteAddr(), c.conn.LocalAddr())
+	}
+}
+
+func TestAccessRules_Refuse(t *testing.T) {
+	_, internal, _ := net.ParseCIDR("10.0.0.0/8")
+	addr := startServer(t, WithSniffTimeout(300*time.Millisecond), WithAccessRules(AccessRule{
+		Protocols: []protocol.ProtocolType{protocol.ProtocolThrift, protocol.ProtocolHttp2},
+		Sources:   []*net.IPNet{internal},
+	}))
+	body := []byte(`{"Name":"bob"}`)
+
+	t.Run("http allowed", func(t *testing.T) {
+		resp, err := http.Post("http://"+addr+"/api/STService/testSTReq", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("thrift denied", func(t *testing.T) {
+		sock, err := thrift.NewTSocket(addr)
+		require.NoError(t, err)
+		require.NoError(t, sock.Open())
+		defer sock.Close()
+		cli := stability.NewSTServiceClientFactory(sock, thrift.NewTBinaryProtocolFactoryDefault())
+		name := "alice"
+		_, err = cli.TestSTReq(context.Background(), &stability.STRequest{Name: &name})
+		var exc thrift.TApplicationException
+		require.True(t, errors.As(err, &exc), "got %v", err)
+		assert.Contains(t, exc.Error(), "not allowed")
+	})
+
+	t.Run("h2c denied", func(t *testing.T) {
+		cli := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS:   func(network, addr string, _ *tls.Config) (net.Conn, error) { return net.Dial(network, addr) },
+		}}
+		resp, err := cli.Post("http://"+addr+"/api/STService/testSTReq", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		_, _ = io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	stats := DetectionStats()
+	assert.GreaterOrEqual(t, stats[OutcomeDenied], uint64(2))
+}
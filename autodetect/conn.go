@@ -0,0 +1,126 @@
+package autodetect
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/netpoll"
+)
+
+// bufferedConnection 把任意 net.Conn 包装为 netpoll.Connection：读取经过 netpoll.Reader 缓冲，
+// 嗅探时预读的字节保留在缓冲区中，之后通过 Read 或 Reader() 都能读到（作用与 PrependConn 相同）。
+// 用于 TLS 解密后的连接以及 Kitex gonet 等非 netpoll 传输层的连接，
+// 使 Kitex 原生 handler、HTTP 与 apachethrift handler 无需修改即可处理这些连接
+type bufferedConnection struct {
+	// Conn 是数据来源，如 *tls.Conn 或原始的 TCP 连接
+	net.Conn
+	// raw 是底层连接，用于超时设置与存活判断
+	raw    net.Conn
+	reader netpoll.Reader
+	writer netpoll.Writer
+	closed int32
+}
+
+var _ netpoll.Connection = (*bufferedConnection)(nil)
+
+func newBufferedConnection(conn, raw net.Conn) *bufferedConnection {
+	c := &bufferedConnection{Conn: conn, raw: raw}
+	c.reader = netpoll.NewReader(&eofReader{conn: c})
+	c.writer = netpoll.NewWriter(conn)
+	return c
+}
+
+// Read 先读出 Reader 中已缓存的数据，保证 net.Conn 与 Reader 两种读法一致
+func (c *bufferedConnection) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	if c.reader.Len() == 0 {
+		if _, err := c.reader.Peek(1); err != nil {
+			return 0, err
+		}
+	}
+	n := c.reader.Len()
+	if n > len(b) {
+		n = len(b)
+	}
+	buf, err := c.reader.Next(n)
+	if err != nil {
+		return 0, err
+	}
+	copy(b, buf)
+	return n, c.reader.Release()
+}
+
+func (c *bufferedConnection) Reader() netpoll.Reader {
+	return c.reader
+}
+
+func (c *bufferedConnection) Writer() netpoll.Writer {
+	return c.writer
+}
+
+func (c *bufferedConnection) IsActive() bool {
+	if atomic.LoadInt32(&c.closed) != 0 {
+		return false
+	}
+	if np, ok := c.raw.(netpoll.Connection); ok {
+		return np.IsActive()
+	}
+	return true
+}
+
+func (c *bufferedConnection) Close() error {
+	atomic.StoreInt32(&c.closed, 1)
+	return c.Conn.Close()
+}
+
+// SetReadTimeout 只对 netpoll 底层连接生效，gonet 传输层自行管理读超时
+func (c *bufferedConnection) SetReadTimeout(timeout time.Duration) error {
+	if np, ok := c.raw.(netpoll.Connection); ok {
+		return np.SetReadTimeout(timeout)
+	}
+	return nil
+}
+
+func (c *bufferedConnection) SetWriteTimeout(timeout time.Duration) error {
+	if np, ok := c.raw.(netpoll.Connection); ok {
+		return np.SetWriteTimeout(timeout)
+	}
+	return nil
+}
+
+func (c *bufferedConnection) SetIdleTimeout(timeout time.Duration) error {
+	if np, ok := c.raw.(netpoll.Connection); ok {
+		return np.SetIdleTimeout(timeout)
+	}
+	return nil
+}
+
+// SetOnRequest 不支持：包装后的连接由所在的 handler 或传输层驱动读取
+func (c *bufferedConnection) SetOnRequest(on netpoll.OnRequest) error {
+	return errors.New("autodetect: SetOnRequest is not supported")
+}
+
+func (c *bufferedConnection) AddCloseCallback(callback netpoll.CloseCallback) error {
+	if np, ok := c.raw.(netpoll.Connection); ok {
+		return np.AddCloseCallback(func(netpoll.Connection) error { return callback(c) })
+	}
+	return nil
+}
+
+// eofReader 从数据来源读取，读到 EOF 或连接错误时把连接标记为关闭，
+// 使 IsActive 能反映对端已断开；读超时（如嗅探超时）不视为断开
+type eofReader struct {
+	conn *bufferedConnection
+}
+
+func (r *eofReader) Read(b []byte) (int, error) {
+	n, err := r.conn.Conn.Read(b)
+	if err != nil && !isTimeout(err) {
+		atomic.StoreInt32(&r.conn.closed, 1)
+	}
+	return n, err
+}
@@ -0,0 +1,93 @@
+package autodetect
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/remote"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// innerHandlers 用于剥离一层封装（TLS、PROXY 头）之后，对剩余的数据重新嗅探并选择协议 handler
+type innerHandlers struct {
+	defaultHandler remote.ServerTransHandler
+	handlers       map[protocol.ProtocolType]remote.ServerTransHandler
+	policy         sniffPolicy
+	rwTimeout      time.Duration
+}
+
+func newInnerHandlers(
+	opt *remote.ServerOption,
+	policy sniffPolicy,
+	defaultFactory remote.ServerTransHandlerFactory,
+	factories map[protocol.ProtocolType]remote.ServerTransHandlerFactory,
+) (*innerHandlers, error) {
+	h := &innerHandlers{
+		handlers:  make(map[protocol.ProtocolType]remote.ServerTransHandler, len(factories)),
+		policy:    policy,
+		rwTimeout: opt.ReadWriteTimeout,
+	}
+	var err error
+	if h.defaultHandler, err = defaultFactory.NewTransHandler(opt); err != nil {
+		return nil, err
+	}
+	for p, factory := range factories {
+		if h.handlers[p], err = factory.NewTransHandler(opt); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// lookup 返回协议对应的 handler，未注册时返回默认 handler
+func (h *innerHandlers) lookup(p protocol.ProtocolType) remote.ServerTransHandler {
+	if t, ok := h.handlers[p]; ok {
+		return t
+	}
+	return h.defaultHandler
+}
+
+// detect 在嗅探超时内预读 conn 中的数据并按已注册的嗅探器选择 handler
+func (h *innerHandlers) detect(ctx context.Context, conn net.Conn) (remote.ServerTransHandler, error) {
+	return h.choose(ctx, conn, h.policy.sniff(conn, h.rwTimeout))
+}
+
+// choose 按嗅探结果选择 handler，未识别或不被访问规则允许的连接返回 error
+func (h *innerHandlers) choose(ctx context.Context, conn net.Conn, res sniffResult) (remote.ServerTransHandler, error) {
+	if err := h.policy.admit(ctx, conn, res, h.rwTimeout); err != nil {
+		return nil, err
+	}
+	return h.lookup(res.protocol), nil
+}
+
+func (h *innerHandlers) all() []remote.ServerTransHandler {
+	all := []remote.ServerTransHandler{h.defaultHandler}
+	for _, t := range h.handlers {
+		all = append(all, t)
+	}
+	return all
+}
+
+func (h *innerHandlers) SetPipeline(pipeline *remote.TransPipeline) {
+	for _, t := range h.all() {
+		t.SetPipeline(pipeline)
+	}
+}
+
+func (h *innerHandlers) SetInvokeHandleFunc(inkHdlFunc endpoint.Endpoint) {
+	for _, t := range h.all() {
+		if s, ok := t.(remote.InvokeHandleFuncSetter); ok {
+			s.SetInvokeHandleFunc(inkHdlFunc)
+		}
+	}
+}
+
+func (h *innerHandlers) GracefulShutdown(ctx context.Context) error {
+	if g, ok := h.defaultHandler.(remote.GracefulShutdown); ok {
+		return g.GracefulShutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package autodetect
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// Options 是协议识别层的可配置项，通过 Option 在创建工厂时设置
+type Options struct {
+	// TLSConfig 非空时识别并终止 TLS
+	TLSConfig *tls.Config
+	// ProxyProtocol 为 true 时接受连接开头的 PROXY v1/v2 头
+	ProxyProtocol bool
+	// TrustedProxies 限制只接受来自这些网段的 PROXY 头，开启 ProxyProtocol 时不能为空
+	TrustedProxies []*net.IPNet
+	// SniffTimeout 是等待首批字节的时间，为 0 时使用 DefaultSniffTimeout
+	SniffTimeout time.Duration
+	// MinSniffBytes 是超时后仍尝试嗅探所需的最少字节数，为 0 时为 1
+	MinSniffBytes int
+	// UnknownAction 决定无法识别协议的连接如何处理
+	UnknownAction UnknownAction
+	// AccessRules 按监听地址与客户端网段限制可接受的协议
+	AccessRules []AccessRule
+}
+
+// Option 用于修改 Options
+type Option func(o *Options)
+
+// WithTLS 使用 config 终止同一端口上的 TLS 连接，config 为 nil 时不识别 TLS
+func WithTLS(config *tls.Config) Option {
+	return func(o *Options) {
+		o.TLSConfig = config
+	}
+}
+
+// WithProxyProtocol 在协议识别前接受并剥离 PROXY v1/v2 头，只信任来自 trusted 网段（通常是 L4 负载均衡器）的 PROXY 头；
+// trusted 为空时服务启动失败（ErrNoTrustedProxies）
+func WithProxyProtocol(trusted ...*net.IPNet) Option {
+	return func(o *Options) {
+		o.ProxyProtocol = true
+		o.TrustedProxies = append(o.TrustedProxies, trusted...)
+	}
+}
+
+// WithSniffTimeout 设置等待客户端发送首批字节的时间，超时仍没有数据的连接会被关闭
+func WithSniffTimeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.SniffTimeout = d
+	}
+}
+
+// WithMinSniffBytes 设置嗅探超时后仍进行识别所需的最少字节数，
+// 到达的字节更少时连接按 UnknownAction 处理
+func WithMinSniffBytes(n int) Option {
+	return func(o *Options) {
+		o.MinSniffBytes = n
+	}
+}
+
+// WithUnknownAction 设置无法识别协议的连接的处理方式，默认交给 Thrift handler
+func WithUnknownAction(action UnknownAction) Option {
+	return func(o *Options) {
+		o.UnknownAction = action
+	}
+}
+
+// WithAccessRules 追加访问规则，例如只接受来自内网的 Thrift、任意来源的 HTTP：
+//
+//	WithAccessRules(AccessRule{Protocols: []protocol.ProtocolType{protocol.ProtocolThrift}, Sources: internal})
+func WithAccessRules(rules ...AccessRule) Option {
+	return func(o *Options) {
+		o.AccessRules = append(o.AccessRules, rules...)
+	}
+}
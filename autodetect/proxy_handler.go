@@ -0,0 +1,183 @@
+package autodetect
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/netpoll"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// NewProxyProtocolHandlerFactory 创建处理 PROXY v1/v2 头的 handler 工厂：剥离 PROXY 头后对剩余数据重新嗅探，
+// 交给 handlers 中对应协议的 handler，内层 handler 看到的 RemoteAddr/LocalAddr 为 PROXY 头中的真实地址。
+// 只有来自 trusted 网段的连接才会解析 PROXY 头，其它连接携带 PROXY 头会被拒绝；
+// trusted 不能为空，否则任意客户端都能伪造来源地址绕过 AccessRule.Sources，NewTransHandler 返回 ErrNoTrustedProxies。
+func NewProxyProtocolHandlerFactory(
+	trusted []*net.IPNet,
+	defaultHandlerFactory remote.ServerTransHandlerFactory,
+	handlers map[protocol.ProtocolType]remote.ServerTransHandlerFactory,
+) remote.ServerTransHandlerFactory {
+	return &proxyHandlerFactory{
+		trusted: trusted, policy: defaultSniffPolicy(), defaultFactory: defaultHandlerFactory, factories: handlers,
+	}
+}
+
+// ErrNoTrustedProxies 表示开启了 PROXY protocol 但没有配置可信网段
+var ErrNoTrustedProxies = errors.New("proxy: PROXY protocol requires trusted proxy CIDRs")
+
+type proxyHandlerFactory struct {
+	trusted        []*net.IPNet
+	policy         sniffPolicy
+	defaultFactory remote.ServerTransHandlerFactory
+	factories      map[protocol.ProtocolType]remote.ServerTransHandlerFactory
+}
+
+func (f *proxyHandlerFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	if len(f.trusted) == 0 {
+		return nil, ErrNoTrustedProxies
+	}
+	inner, err := newInnerHandlers(opt, f.policy, f.defaultFactory, f.factories)
+	if err != nil {
+		return nil, err
+	}
+	return &proxyHandler{innerHandlers: inner, trusted: f.trusted}, nil
+}
+
+type proxyHandler struct {
+	*innerHandlers
+	trusted []*net.IPNet
+}
+
+// proxySession 记录替换了地址的连接与选中的内层 handler
+type proxySession struct {
+	conn    *proxyConnection
+	handler remote.ServerTransHandler
+	ctx     context.Context
+}
+
+type proxySessionKey struct{}
+
+func proxySessionFromContext(ctx context.Context) *proxySession {
+	s, _ := ctx.Value(proxySessionKey{}).(*proxySession)
+	return s
+}
+
+// OnActive 读取并消费 PROXY 头。netpoll 连接的预读数据保留在连接缓冲区中，
+// 头部之后的字节无需像 PrependConn 那样重新注入，内层 handler 可以直接读取
+func (h *proxyHandler) OnActive(ctx context.Context, conn net.Conn) (context.Context, error) {
+	np, ok := conn.(netpoll.Connection)
+	if !ok {
+		return ctx, protocol.ErrPeekUnsupported
+	}
+	if !h.isTrusted(conn.RemoteAddr()) {
+		return ctx, fmt.Errorf("proxy: PROXY header from untrusted source %s", conn.RemoteAddr())
+	}
+	// v1 头可能被拆成多段发送，读取同样受嗅探超时约束
+	restore := setSniffDeadline(np, h.policy.timeout, h.rwTimeout)
+	hdr, err := protocol.ReadProxyHeader(np.Reader())
+	restore()
+	if err != nil {
+		return ctx, err
+	}
+	pc := newProxyConnection(np, hdr)
+	inner, err := h.detect(ctx, pc)
+	if err != nil {
+		return ctx, err
+	}
+	// 内层 handler 的 ctx 需要能找到本层的 session：Kitex pipeline 的 Read/Write 会从最外层重新分发
+	session := &proxySession{conn: pc, handler: inner}
+	ctx = context.WithValue(protocol.WithProxyHeader(ctx, hdr), proxySessionKey{}, session)
+	if session.ctx, err = inner.OnActive(ctx, pc); err != nil {
+		return ctx, err
+	}
+	return session.ctx, nil
+}
+
+func (h *proxyHandler) isTrusted(addr net.Addr) bool {
+	ip := addrIP(addr)
+	for _, n := range h.trusted {
+		if ip != nil && n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func addrIP(addr net.Addr) net.IP {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+func (h *proxyHandler) OnRead(ctx context.Context, conn net.Conn) error {
+	s := proxySessionFromContext(ctx)
+	if s == nil {
+		return errors.New("proxy: connection is not initialized")
+	}
+	return s.handler.OnRead(s.ctx, s.conn)
+}
+
+func (h *proxyHandler) Read(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	s := proxySessionFromContext(ctx)
+	return s.handler.Read(ctx, s.conn, msg)
+}
+
+func (h *proxyHandler) Write(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	s := proxySessionFromContext(ctx)
+	return s.handler.Write(ctx, s.conn, msg)
+}
+
+func (h *proxyHandler) OnInactive(ctx context.Context, conn net.Conn) {
+	if s := proxySessionFromContext(ctx); s != nil {
+		s.handler.OnInactive(s.ctx, s.conn)
+	}
+}
+
+func (h *proxyHandler) OnError(ctx context.Context, err error, conn net.Conn) {
+	if s := proxySessionFromContext(ctx); s != nil {
+		s.handler.OnError(ctx, err, s.conn)
+		return
+	}
+	klog.CtxErrorf(ctx, "KITEX: proxy protocol connection error, remote=%s, error=%v", conn.RemoteAddr(), err)
+}
+
+func (h *proxyHandler) OnMessage(ctx context.Context, args, result remote.Message) (context.Context, error) {
+	if s := proxySessionFromContext(ctx); s != nil {
+		return s.handler.OnMessage(ctx, args, result)
+	}
+	return h.defaultHandler.OnMessage(ctx, args, result)
+}
+
+// proxyConnection 用 PROXY 头中的地址替换连接的 RemoteAddr 与 LocalAddr，
+// 各 handler 通过 conn.RemoteAddr() 构造 rpcinfo 的 From 地址
+type proxyConnection struct {
+	netpoll.Connection
+	remote net.Addr
+	local  net.Addr
+}
+
+func newProxyConnection(conn netpoll.Connection, hdr *protocol.ProxyHeader) *proxyConnection {
+	pc := &proxyConnection{Connection: conn, remote: conn.RemoteAddr(), local: conn.LocalAddr()}
+	if hdr.Source != nil {
+		pc.remote, pc.local = hdr.Source, hdr.Destination
+	}
+	return pc
+}
+
+func (c *proxyConnection) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *proxyConnection) LocalAddr() net.Addr {
+	return c.local
+}
@@ -0,0 +1,144 @@
+package autodetect
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/http1"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// proxyTransport 在连接建立后先发送 PROXY 头，再交给 Thrift 客户端使用
+type proxyTransport struct {
+	thrift.TTransport
+	header []byte
+	sent   bool
+}
+
+func (p *proxyTransport) Write(b []byte) (int, error) {
+	if !p.sent {
+		p.sent = true
+		if _, err := p.TTransport.Write(p.header); err != nil {
+			return 0, err
+		}
+	}
+	return p.TTransport.Write(b)
+}
+
+func proxyV2Header(src, dst *net.TCPAddr) []byte {
+	h := []byte("\r\n\r\n\x00\r\nQUIT\n")
+	h = append(h, 0x21, 0x11, 0x00, 0x0c)
+	h = append(h, src.IP.To4()...)
+	h = append(h, dst.IP.To4()...)
+	return append(h, byte(src.Port>>8), byte(src.Port), byte(dst.Port>>8), byte(dst.Port))
+}
+
+func TestProxyProtocolHandler(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	addr := startServer(t, WithProxyProtocol(loopback), WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+
+	t.Run("v1 http", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer conn.Close()
+		fmt.Fprintf(conn, "PROXY TCP4 203.0.113.7 10.0.0.1 40000 8888\r\n"+
+			"GET /api/STService/testSTReq?Name=from HTTP/1.1\r\nConnection: close\r\n\r\n")
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		out, _ := io.ReadAll(conn)
+		assert.Contains(t, string(out), `"str":"203.0.113.7:40000"`)
+	})
+
+	t.Run("v2 thrift", func(t *testing.T) {
+		sock := thrift.NewTSocketFromAddrTimeout(mustResolve(t, addr), 3*time.Second)
+		require.NoError(t, sock.Open())
+		defer sock.Close()
+		src := &net.TCPAddr{IP: net.ParseIP("198.51.100.9"), Port: 50001}
+		trans := &proxyTransport{TTransport: sock, header: proxyV2Header(src, mustResolve(t, addr).(*net.TCPAddr))}
+		cli := stability.NewSTServiceClientFactory(thrift.NewTFramedTransport(trans), thrift.NewTBinaryProtocolFactoryDefault())
+		name := "from"
+		for i := 0; i < 2; i++ {
+			resp, err := cli.TestSTReq(context.Background(), &stability.STRequest{Name: &name})
+			require.NoError(t, err)
+			assert.Equal(t, src.String(), resp.GetStr())
+		}
+	})
+
+	t.Run("v1 tls", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer conn.Close()
+		fmt.Fprintf(conn, "PROXY TCP4 192.0.2.44 10.0.0.1 41000 8888\r\n")
+		tc := tls.Client(conn, &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"})
+		fmt.Fprintf(tc, "GET /api/STService/testSTReq?Name=from HTTP/1.1\r\nConnection: close\r\n\r\n")
+		tc.SetReadDeadline(time.Now().Add(3 * time.Second))
+		out, _ := io.ReadAll(tc)
+		assert.Contains(t, string(out), `"str":"192.0.2.44:41000"`)
+	})
+
+	t.Run("plain connection still works", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer conn.Close()
+		fmt.Fprintf(conn, "GET /api/STService/testSTReq?Name=from HTTP/1.1\r\nConnection: close\r\n\r\n")
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		out, _ := io.ReadAll(conn)
+		assert.True(t, strings.Contains(string(out), `"str":"127.0.0.1:`), string(out))
+	})
+}
+
+func TestProxyProtocolHandler_Untrusted(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	addr := startServer(t, WithProxyProtocol(trusted))
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	fmt.Fprintf(conn, "PROXY TCP4 203.0.113.7 10.0.0.1 40000 8888\r\n"+
+		"GET /api/STService/testSTReq?Name=from HTTP/1.1\r\nConnection: close\r\n\r\n")
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	out, _ := io.ReadAll(conn)
+	assert.Empty(t, string(out))
+}
+
+// 不可信来源伪造的 PROXY 头不能用来通过按来源限制的访问规则
+func TestProxyProtocolHandler_ForgedSource(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("192.0.2.0/24")
+	_, internal, _ := net.ParseCIDR("10.0.0.0/8")
+	addr := startServer(t, WithProxyProtocol(trusted), WithAccessRules(AccessRule{
+		Protocols: []protocol.ProtocolType{protocol.ProtocolThrift},
+		Sources:   []*net.IPNet{internal},
+	}))
+	sock := thrift.NewTSocketFromAddrTimeout(mustResolve(t, addr), 3*time.Second)
+	require.NoError(t, sock.Open())
+	defer sock.Close()
+	src := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 50001}
+	trans := &proxyTransport{TTransport: sock, header: proxyV2Header(src, mustResolve(t, addr).(*net.TCPAddr))}
+	cli := stability.NewSTServiceClientFactory(thrift.NewTFramedTransport(trans), thrift.NewTBinaryProtocolFactoryDefault())
+	name := "forged"
+	_, err := cli.TestSTReq(context.Background(), &stability.STRequest{Name: &name})
+	assert.Error(t, err)
+}
+
+func TestProxyProtocolHandler_RequiresTrusted(t *testing.T) {
+	f := NewSvrTransHandlerFactoryWithOptions(http1.NewSvrTransHandlerFactory(), http1.NewHTTP2SvrTransHandlerFactory(), WithProxyProtocol())
+	_, err := f.NewTransHandler(&remote.ServerOption{})
+	assert.ErrorIs(t, err, ErrNoTrustedProxies)
+}
+
+func mustResolve(t *testing.T, addr string) net.Addr {
+	a, err := net.ResolveTCPAddr("tcp", addr)
+	require.NoError(t, err)
+	return a
+}
@@ -0,0 +1,219 @@
+package autodetect
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/netpoll"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// DefaultSniffTimeout 是等待客户端发送首批字节的默认时间
+const DefaultSniffTimeout = 10 * time.Second
+
+// UnknownAction 决定无法识别协议的连接如何处理
+type UnknownAction int
+
+const (
+	// UnknownFallbackThrift 交给默认的 Thrift handler 处理（默认行为）
+	UnknownFallbackThrift UnknownAction = iota
+	// UnknownClose 直接关闭连接
+	UnknownClose
+	// UnknownHTTP400 回复一个原始的 HTTP 400 后关闭连接
+	UnknownHTTP400
+)
+
+func (a UnknownAction) String() string {
+	switch a {
+	case UnknownClose:
+		return "close"
+	case UnknownHTTP400:
+		return "http400"
+	default:
+		return "thrift"
+	}
+}
+
+// ParseUnknownAction 解析配置中的 "close"、"thrift"、"http400"，空串视为 "thrift"
+func ParseUnknownAction(s string) (UnknownAction, error) {
+	switch s {
+	case "", "thrift":
+		return UnknownFallbackThrift, nil
+	case "close":
+		return UnknownClose, nil
+	case "http400":
+		return UnknownHTTP400, nil
+	}
+	return UnknownFallbackThrift, errors.New("autodetect: unknown protocol action " + s)
+}
+
+// 嗅探结果中未命中任何协议时的分类，命中时使用嗅探器的 Name
+const (
+	OutcomeUnknown = "unknown" // 数据足够但没有嗅探器匹配
+	OutcomeShort   = "short"   // 超时前到达的字节少于 MinSniffBytes
+	OutcomeTimeout = "timeout" // 超时前没有收到任何数据
+	OutcomeClosed  = "closed"  // 发送数据前对端已关闭连接
+)
+
+var (
+	// errUnknownProtocol 表示连接按 UnknownClose / UnknownHTTP400 被拒绝
+	errUnknownProtocol = errors.New("autodetect: unknown protocol")
+	// ErrSniffTimeout 表示连接在嗅探超时前没有发送任何数据
+	ErrSniffTimeout = errors.New("autodetect: no data before sniff deadline")
+
+	http400Response = []byte("HTTP/1.1 400 Bad Request\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Length: 17\r\n" +
+		"Connection: close\r\n\r\n" +
+		"unknown protocol\n")
+)
+
+// sniffPolicy 是 Options 中与嗅探及嗅探结果处理有关的部分
+type sniffPolicy struct {
+	timeout  time.Duration
+	minBytes int
+	action   UnknownAction
+	access   accessRules
+}
+
+func (o *Options) sniffPolicy() sniffPolicy {
+	p := sniffPolicy{
+		timeout: o.SniffTimeout, minBytes: o.MinSniffBytes, action: o.UnknownAction, access: o.AccessRules,
+	}
+	if p.timeout <= 0 {
+		p.timeout = DefaultSniffTimeout
+	}
+	if p.minBytes <= 0 {
+		p.minBytes = 1
+	}
+	if max := protocol.MaxPeek(); p.minBytes > max {
+		p.minBytes = max
+	}
+	return p
+}
+
+func defaultSniffPolicy() sniffPolicy {
+	var o Options
+	return o.sniffPolicy()
+}
+
+// sniffResult 是一次嗅探的结果，protocol 为 ProtocolUnknown 时 outcome 说明原因
+type sniffResult struct {
+	protocol protocol.ProtocolType
+	outcome  string
+}
+
+// sniff 在 timeout 内预读连接开头的字节并识别协议，数据不足 MaxPeek 时用已到达的部分嗅探，
+// 不会因为客户端沉默或只发送几个字节而无限等待。rwTimeout 是嗅探结束后恢复的 netpoll 读超时
+func (p sniffPolicy) sniff(conn net.Conn, rwTimeout time.Duration) sniffResult {
+	reader, ok := protocol.ConnReader(conn)
+	if !ok {
+		// 调用方保证连接可预读，这里只是防御
+		return sniffResult{protocol: protocol.ProtocolUnknown, outcome: OutcomeUnknown}
+	}
+	restore := setSniffDeadline(conn, p.timeout, rwTimeout)
+	_, err := reader.Peek(protocol.MaxPeek())
+	restore()
+	peek, _ := reader.Peek(reader.Len())
+	return p.classify(peek, err)
+}
+
+// err 返回无法继续处理连接的原因：对端已关闭或沉默超时，其它结果返回 nil
+func (r sniffResult) err() error {
+	switch r.outcome {
+	case OutcomeClosed:
+		return io.EOF
+	case OutcomeTimeout:
+		return ErrSniffTimeout
+	}
+	return nil
+}
+
+// classify 根据预读到的数据与预读错误得出嗅探结果并计数
+func (p sniffPolicy) classify(peek []byte, err error) sniffResult {
+	var res sniffResult
+	switch n := len(peek); {
+	case err == nil || n >= p.minBytes:
+		res.protocol = protocol.Sniff(peek)
+		res.outcome = OutcomeUnknown
+		if s, ok := protocol.LookupSniffer(res.protocol); ok {
+			res.outcome = s.Name
+		}
+	case !isTimeout(err):
+		res.outcome = OutcomeClosed
+	case n == 0:
+		res.outcome = OutcomeTimeout
+	default:
+		res.outcome = OutcomeShort
+	}
+	countOutcome(res.outcome)
+	return res
+}
+
+// reject 按策略处理未识别的连接：返回 nil 表示交给默认 handler，否则连接应被关闭
+func (p sniffPolicy) reject(conn net.Conn, res sniffResult) error {
+	if err := res.err(); err != nil {
+		return err
+	}
+	switch p.action {
+	case UnknownClose:
+		return errUnknownProtocol
+	case UnknownHTTP400:
+		_, _ = conn.Write(http400Response)
+		return errUnknownProtocol
+	}
+	return nil
+}
+
+// setSniffDeadline 为嗅探设置读超时并返回恢复函数：
+// netpoll 连接使用 SetReadTimeout，恢复为传输层配置的读超时；其它连接使用 SetReadDeadline
+func setSniffDeadline(conn net.Conn, timeout, rwTimeout time.Duration) (restore func()) {
+	target := conn
+	if bc, ok := conn.(*bufferedConnection); ok {
+		// 解密后的连接：底层是 netpoll 原生连接时设置其读超时，否则（gonet）设置数据来源的 deadline
+		if _, wrapped := bc.raw.(*bufferedConnection); !wrapped {
+			if np, ok := bc.raw.(netpoll.Connection); ok {
+				_ = np.SetReadTimeout(timeout)
+				return func() { _ = np.SetReadTimeout(rwTimeout) }
+			}
+		}
+		target = bc.Conn
+	} else if np, ok := conn.(netpoll.Connection); ok {
+		_ = np.SetReadTimeout(timeout)
+		return func() { _ = np.SetReadTimeout(rwTimeout) }
+	}
+	_ = target.SetReadDeadline(time.Now().Add(timeout))
+	return func() { _ = target.SetReadDeadline(time.Time{}) }
+}
+
+func isTimeout(err error) bool {
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
+
+var outcomes sync.Map // outcome -> *uint64
+
+func countOutcome(outcome string) {
+	v, ok := outcomes.Load(outcome)
+	if !ok {
+		v, _ = outcomes.LoadOrStore(outcome, new(uint64))
+	}
+	atomic.AddUint64(v.(*uint64), 1)
+}
+
+// DetectionStats 返回进程内各嗅探结果的累计次数，键为命中的嗅探器名称（如 "http1"、"thrift"、"tls"）
+// 或 OutcomeUnknown、OutcomeShort、OutcomeTimeout、OutcomeClosed。
+// TLS、PROXY 剥离后的每次重新嗅探也会计数
+func DetectionStats() map[string]uint64 {
+	stats := make(map[string]uint64)
+	outcomes.Range(func(k, v any) bool {
+		stats[k.(string)] = atomic.LoadUint64(v.(*uint64))
+		return true
+	})
+	return stats
+}
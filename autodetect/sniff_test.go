@@ -0,0 +1,117 @@
+package autodetect
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/remote/trans/gonet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// readAll 发送 data 后读取服务端的全部回复，直到服务端关闭连接
+func readAll(t *testing.T, addr string, data []byte) (string, time.Duration) {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+	if len(data) > 0 {
+		_, err = conn.Write(data)
+		require.NoError(t, err)
+	}
+	start := time.Now()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	out, err := io.ReadAll(conn)
+	require.NoError(t, err, "server should close the connection")
+	return string(out), time.Since(start)
+}
+
+func TestSniffPolicy(t *testing.T) {
+	transports := map[string]remote.TransServerFactory{"netpoll": nil, "gonet": gonet.NewTransServerFactory()}
+	for name, transServer := range transports {
+		t.Run(name, func(t *testing.T) {
+			before := DetectionStats()
+			addr := startServerOn(t, transServer,
+				WithSniffTimeout(300*time.Millisecond), WithMinSniffBytes(4), WithUnknownAction(UnknownHTTP400))
+
+			t.Run("silent client", func(t *testing.T) {
+				out, elapsed := readAll(t, addr, nil)
+				assert.Empty(t, out)
+				assert.Less(t, elapsed, 3*time.Second)
+			})
+
+			t.Run("too few bytes", func(t *testing.T) {
+				out, _ := readAll(t, addr, []byte("PO"))
+				assert.Contains(t, out, "HTTP/1.1 400 Bad Request")
+			})
+
+			t.Run("unknown protocol", func(t *testing.T) {
+				out, _ := readAll(t, addr, []byte("SSH-2.0-OpenSSH_9.6\r\n"))
+				assert.Contains(t, out, "HTTP/1.1 400 Bad Request")
+			})
+
+			t.Run("slow http client", func(t *testing.T) {
+				// 超时前只到达 "GET "：已到达的字节足够识别为 HTTP，之后的数据由 HTTP handler 继续读取
+				conn, err := net.Dial("tcp", addr)
+				require.NoError(t, err)
+				defer conn.Close()
+				_, err = conn.Write([]byte("GET "))
+				require.NoError(t, err)
+				time.Sleep(500 * time.Millisecond)
+				_, err = conn.Write([]byte("/ HTTP/1.1\r\nConnection: close\r\n\r\n"))
+				require.NoError(t, err)
+				require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+				out, _ := io.ReadAll(conn)
+				assert.Contains(t, string(out), "HTTP/1.1 ")
+				assert.NotContains(t, string(out), "400 Bad Request")
+			})
+
+			after := DetectionStats()
+			assert.Greater(t, after[OutcomeTimeout], before[OutcomeTimeout])
+			assert.Greater(t, after[OutcomeShort], before[OutcomeShort])
+			assert.Greater(t, after[OutcomeUnknown], before[OutcomeUnknown])
+			assert.Greater(t, after["http1"], before["http1"])
+		})
+	}
+}
+
+func TestSniffPolicy_Close(t *testing.T) {
+	addr := startServer(t, WithSniffTimeout(300*time.Millisecond), WithUnknownAction(UnknownClose))
+	out, _ := readAll(t, addr, []byte("SSH-2.0-OpenSSH_9.6\r\n"))
+	assert.Empty(t, out)
+
+	// 正常请求不受影响
+	resp, err := http.Post("http://"+addr+"/api/STService/testSTReq", "application/json",
+		bytes.NewReader([]byte(`{"Name":"bob"}`)))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestDetect_Timeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	p, _, err := Detect(server, WithSniffTimeout(100*time.Millisecond))
+	assert.ErrorIs(t, err, ErrSniffTimeout)
+	assert.Equal(t, protocol.ProtocolUnknown, p)
+}
+
+func TestParseUnknownAction(t *testing.T) {
+	for s, want := range map[string]UnknownAction{
+		"": UnknownFallbackThrift, "thrift": UnknownFallbackThrift, "close": UnknownClose, "http400": UnknownHTTP400,
+	} {
+		got, err := ParseUnknownAction(s)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := ParseUnknownAction("drop")
+	assert.Error(t, err)
+}
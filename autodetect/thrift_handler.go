@@ -0,0 +1,106 @@
+package autodetect
+
+import (
+	"context"
+	"net"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/remote"
+
+	"github.com/BeroKiTeer/KitBridge/apachethrift"
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// NewThriftHandlerFactory 创建按 Thrift 变体分发的 handler 工厂：
+// buffered / framed / TTHeader 交给 Kitex 原生的 handler（nativeFactory），
+// compact、non-strict binary 与 Apache THeader 交给 apachethrift 处理。
+// 识别出的变体保存在连接 ctx 中，业务代码可以通过 protocol.ThriftVariantFromContext 获取。
+func NewThriftHandlerFactory(nativeFactory remote.ServerTransHandlerFactory) remote.ServerTransHandlerFactory {
+	return &thriftHandlerFactory{
+		native: nativeFactory,
+		apache: apachethrift.NewSvrTransHandlerFactory(),
+	}
+}
+
+type thriftHandlerFactory struct {
+	native remote.ServerTransHandlerFactory
+	apache remote.ServerTransHandlerFactory
+}
+
+func (f *thriftHandlerFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	native, err := f.native.NewTransHandler(opt)
+	if err != nil {
+		return nil, err
+	}
+	apache, err := f.apache.NewTransHandler(opt)
+	if err != nil {
+		return nil, err
+	}
+	return &thriftHandler{native: native, apache: apache}, nil
+}
+
+type thriftHandler struct {
+	native remote.ServerTransHandler
+	apache remote.ServerTransHandler
+}
+
+// which 按连接 ctx 中的变体选择 handler
+func (h *thriftHandler) which(ctx context.Context) remote.ServerTransHandler {
+	if v := protocol.ThriftVariantFromContext(ctx); v != protocol.ThriftVariantUnknown && !v.KitexNative() {
+		return h.apache
+	}
+	return h.native
+}
+
+func (h *thriftHandler) OnActive(ctx context.Context, conn net.Conn) (context.Context, error) {
+	peek, err := protocol.PeekConn(conn, protocol.ThriftPeekSize)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = protocol.WithThriftVariant(ctx, protocol.ClassifyThrift(peek))
+	return h.which(ctx).OnActive(ctx, conn)
+}
+
+func (h *thriftHandler) OnRead(ctx context.Context, conn net.Conn) error {
+	return h.which(ctx).OnRead(ctx, conn)
+}
+
+func (h *thriftHandler) Read(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return h.which(ctx).Read(ctx, conn, msg)
+}
+
+func (h *thriftHandler) Write(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return h.which(ctx).Write(ctx, conn, msg)
+}
+
+func (h *thriftHandler) OnInactive(ctx context.Context, conn net.Conn) {
+	h.which(ctx).OnInactive(ctx, conn)
+}
+
+func (h *thriftHandler) OnError(ctx context.Context, err error, conn net.Conn) {
+	h.which(ctx).OnError(ctx, err, conn)
+}
+
+func (h *thriftHandler) OnMessage(ctx context.Context, args, result remote.Message) (context.Context, error) {
+	return h.which(ctx).OnMessage(ctx, args, result)
+}
+
+func (h *thriftHandler) SetPipeline(pipeline *remote.TransPipeline) {
+	h.native.SetPipeline(pipeline)
+	h.apache.SetPipeline(pipeline)
+}
+
+func (h *thriftHandler) SetInvokeHandleFunc(inkHdlFunc endpoint.Endpoint) {
+	for _, t := range []remote.ServerTransHandler{h.native, h.apache} {
+		if s, ok := t.(remote.InvokeHandleFuncSetter); ok {
+			s.SetInvokeHandleFunc(inkHdlFunc)
+		}
+	}
+}
+
+func (h *thriftHandler) GracefulShutdown(ctx context.Context) error {
+	if g, ok := h.native.(remote.GracefulShutdown); ok {
+		return g.GracefulShutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package autodetect
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/remote"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// defaultHandshakeTimeout 是 TLS 握手的默认超时时间
+const defaultHandshakeTimeout = 10 * time.Second
+
+// ErrNilTLSConfig 表示 NewTLSHandlerFactory 的 config 为 nil
+var ErrNilTLSConfig = errors.New("autodetect: tls config is nil")
+
+// alpnProtocols 把 ALPN 协商结果映射为内层协议，协商成功时不再嗅探
+var alpnProtocols = map[string]protocol.ProtocolType{
+	"h2":       protocol.ProtocolHttp2,
+	"http/1.1": protocol.ProtocolHttp,
+}
+
+// NewTLSHandlerFactory 创建终止 TLS 的 handler 工厂：握手完成后对解密后的数据流重新嗅探，
+// 交给 handlers 中对应协议的 handler 处理，都未命中时使用 defaultHandlerFactory。
+// config 未设置 NextProtos 时，按 handlers 中支持的协议声明 ALPN（h2、http/1.1）；
+// config 不能为 nil，否则 NewTransHandler 返回 ErrNilTLSConfig。
+func NewTLSHandlerFactory(
+	config *tls.Config,
+	defaultHandlerFactory remote.ServerTransHandlerFactory,
+	handlers map[protocol.ProtocolType]remote.ServerTransHandlerFactory,
+) remote.ServerTransHandlerFactory {
+	return newTLSHandlerFactory(config, defaultSniffPolicy(), defaultHandlerFactory, handlers)
+}
+
+func newTLSHandlerFactory(
+	config *tls.Config,
+	policy sniffPolicy,
+	defaultHandlerFactory remote.ServerTransHandlerFactory,
+	handlers map[protocol.ProtocolType]remote.ServerTransHandlerFactory,
+) *tlsHandlerFactory {
+	config = config.Clone()
+	if config != nil && len(config.NextProtos) == 0 {
+		for _, proto := range []string{"h2", "http/1.1"} {
+			if _, ok := handlers[alpnProtocols[proto]]; ok {
+				config.NextProtos = append(config.NextProtos, proto)
+			}
+		}
+	}
+	return &tlsHandlerFactory{config: config, policy: policy, defaultFactory: defaultHandlerFactory, factories: handlers}
+}
+
+type tlsHandlerFactory struct {
+	config         *tls.Config
+	policy         sniffPolicy
+	defaultFactory remote.ServerTransHandlerFactory
+	factories      map[protocol.ProtocolType]remote.ServerTransHandlerFactory
+}
+
+func (f *tlsHandlerFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	if f.config == nil {
+		return nil, ErrNilTLSConfig
+	}
+	inner, err := newInnerHandlers(opt, f.policy, f.defaultFactory, f.factories)
+	if err != nil {
+		return nil, err
+	}
+	return &tlsHandler{innerHandlers: inner, config: f.config}, nil
+}
+
+type tlsHandler struct {
+	*innerHandlers
+	config *tls.Config
+}
+
+// tlsSession 记录一个 TLS 连接解密后的连接与选中的内层 handler
+type tlsSession struct {
+	conn    *bufferedConnection
+	handler remote.ServerTransHandler
+	ctx     context.Context
+}
+
+type tlsSessionKey struct{}
+
+func tlsSessionFromContext(ctx context.Context) *tlsSession {
+	s, _ := ctx.Value(tlsSessionKey{}).(*tlsSession)
+	return s
+}
+
+// OnActive 完成握手并确定内层协议：优先使用 ALPN 协商结果，否则对解密后的数据重新嗅探
+func (h *tlsHandler) OnActive(ctx context.Context, conn net.Conn) (context.Context, error) {
+	tc := tls.Server(conn, h.config)
+	hsCtx, cancel := context.WithTimeout(ctx, defaultHandshakeTimeout)
+	defer cancel()
+	err := tc.HandshakeContext(hsCtx)
+	if err != nil {
+		return ctx, fmt.Errorf("tls handshake: %w", err)
+	}
+	sc := newBufferedConnection(tc, conn)
+
+	var inner remote.ServerTransHandler
+	if proto, ok := alpnProtocols[tc.ConnectionState().NegotiatedProtocol]; ok {
+		inner, err = h.choose(ctx, sc, sniffResult{protocol: proto})
+	} else {
+		inner, err = h.detect(ctx, sc)
+	}
+	if err != nil {
+		return ctx, err
+	}
+	// 内层 handler 的 ctx 需要能找到本层的 session：Kitex pipeline 的 Read/Write 会从最外层重新分发
+	session := &tlsSession{conn: sc, handler: inner}
+	ctx = context.WithValue(ctx, tlsSessionKey{}, session)
+	if session.ctx, err = inner.OnActive(ctx, sc); err != nil {
+		return ctx, err
+	}
+	return session.ctx, nil
+}
+
+// OnRead 在连接生命周期内持续驱动内层 handler：解密后的连接不会触发 netpoll 的可读事件，
+// 内层 handler 每次 OnRead 处理完已到达的请求后返回，这里继续等待下一个请求
+func (h *tlsHandler) OnRead(ctx context.Context, conn net.Conn) error {
+	s := tlsSessionFromContext(ctx)
+	if s == nil {
+		return errors.New("tls: connection is not initialized")
+	}
+	for s.conn.IsActive() {
+		if err := s.handler.OnRead(s.ctx, s.conn); err != nil {
+			if !s.conn.IsActive() {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *tlsHandler) Read(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	s := tlsSessionFromContext(ctx)
+	return s.handler.Read(ctx, s.conn, msg)
+}
+
+func (h *tlsHandler) Write(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	s := tlsSessionFromContext(ctx)
+	return s.handler.Write(ctx, s.conn, msg)
+}
+
+func (h *tlsHandler) OnInactive(ctx context.Context, conn net.Conn) {
+	if s := tlsSessionFromContext(ctx); s != nil {
+		s.handler.OnInactive(s.ctx, s.conn)
+	}
+}
+
+func (h *tlsHandler) OnError(ctx context.Context, err error, conn net.Conn) {
+	if s := tlsSessionFromContext(ctx); s != nil {
+		s.handler.OnError(ctx, err, s.conn)
+		return
+	}
+	klog.CtxErrorf(ctx, "KITEX: tls connection error, remote=%s, error=%v", conn.RemoteAddr(), err)
+}
+
+func (h *tlsHandler) OnMessage(ctx context.Context, args, result remote.Message) (context.Context, error) {
+	if s := tlsSessionFromContext(ctx); s != nil {
+		return s.handler.OnMessage(ctx, args, result)
+	}
+	return h.defaultHandler.OnMessage(ctx, args, result)
+}
@@ -0,0 +1,145 @@
+package autodetect
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/BeroKiTeer/KitBridge/http1"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability/stservice"
+)
+
+type echoService struct{}
+
+// TestSTReq 原样返回 Name；Name 为 "from" 时在 Str 中返回 rpcinfo 记录的客户端地址
+func (*echoService) TestSTReq(ctx context.Context, req *stability.STRequest) (*stability.STResponse, error) {
+	resp := &stability.STResponse{Name: req.Name}
+	if req.GetName() == "from" {
+		from := rpcinfo.GetRPCInfo(ctx).From().Address().String()
+		resp.Str = &from
+	}
+	return resp, nil
+}
+
+// selfSignedCert 生成 127.0.0.1 的自签名证书，返回服务端证书与信任该证书的 CertPool
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kitbridge-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool
+}
+
+func startServer(t *testing.T, opts ...Option) string {
+	return startServerOn(t, nil, opts...)
+}
+
+// startServerOn 使用指定的 Kitex 传输层启动服务，transServer 为 nil 时使用默认的 netpoll
+func startServerOn(t *testing.T, transServer remote.TransServerFactory, opts ...Option) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	factory := NewSvrTransHandlerFactoryWithOptions(http1.NewSvrTransHandlerFactory(), http1.NewHTTP2SvrTransHandlerFactory(), opts...)
+	svrOpts := []server.Option{server.WithListener(ln), server.WithTransHandlerFactory(factory)}
+	if transServer != nil {
+		svrOpts = append(svrOpts, server.WithTransServerFactory(transServer))
+	}
+	svr := stservice.NewServer(&echoService{}, svrOpts...)
+	go svr.Run()
+	if transServer == nil {
+		// gonet 传输层在 Stop 后 Accept 失败会直接退出进程，只停止 netpoll 服务
+		t.Cleanup(func() { svr.Stop() })
+	}
+	time.Sleep(200 * time.Millisecond)
+	return ln.Addr().String()
+}
+
+func TestTLSHandler(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	addr := startServer(t, WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+	clientTLS := &tls.Config{RootCAs: pool}
+	body := []byte(`{"Name":"bob"}`)
+	want := `{"code":200,"message":"success","data":{"name":"bob"}}`
+
+	t.Run("https", func(t *testing.T) {
+		// 不声明 ALPN，由解密后的数据重新嗅探出 HTTP/1.1
+		cli := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: clientTLS,
+			TLSNextProto:    map[string]func(string, *tls.Conn) http.RoundTripper{},
+		}}
+		for i := 0; i < 2; i++ {
+			resp, err := cli.Post("https://"+addr+"/api/STService/testSTReq", "application/json", bytes.NewReader(body))
+			require.NoError(t, err)
+			out, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			assert.Equal(t, "HTTP/1.1", resp.Proto)
+			assert.Equal(t, want, string(out))
+		}
+	})
+
+	t.Run("h2 via alpn", func(t *testing.T) {
+		cli := &http.Client{Transport: &http2.Transport{TLSClientConfig: clientTLS}}
+		resp, err := cli.Post("https://"+addr+"/api/STService/testSTReq", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "HTTP/2.0", resp.Proto)
+		assert.Equal(t, want, string(out))
+	})
+
+	t.Run("thrift", func(t *testing.T) {
+		for _, framed := range []bool{false, true} {
+			sock, err := thrift.NewTSSLSocket(addr, clientTLS)
+			require.NoError(t, err)
+			require.NoError(t, sock.Open())
+			var trans thrift.TTransport = sock
+			if framed {
+				trans = thrift.NewTFramedTransport(sock)
+			}
+			cli := stability.NewSTServiceClientFactory(trans, thrift.NewTBinaryProtocolFactoryDefault())
+			name := "alice"
+			resp, err := cli.TestSTReq(context.Background(), &stability.STRequest{Name: &name})
+			require.NoError(t, err, "framed=%v", framed)
+			assert.Equal(t, name, resp.GetName())
+			sock.Close()
+		}
+	})
+}
+
+func TestTLSHandlerFactory_NilConfig(t *testing.T) {
+	f := NewTLSHandlerFactory(nil, http1.NewSvrTransHandlerFactory(), nil)
+	_, err := f.NewTransHandler(&remote.ServerOption{})
+	assert.ErrorIs(t, err, ErrNilTLSConfig)
+}
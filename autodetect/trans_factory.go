@@ -1,11 +1,18 @@
 package autodetect
 
 import (
-	"regexp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
 
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/cloudwego/kitex/pkg/remote"
 	"github.com/cloudwego/kitex/pkg/remote/trans/detection"
 	"github.com/cloudwego/kitex/pkg/remote/trans/netpoll"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
 )
 
 // ====================================================================
@@ -15,12 +22,17 @@ import (
 // 请求的读取（Read）与响应的写入（Write）。
 //
 // 本文件中使用 detection.NewSvrTransHandlerFactory 实现了“协议嗅探工厂”，
-// 支持注册多个 handler，并通过 ProtocolMatch 方法在连接建立阶段判断
+// 支持注册多个 handler，并通过 protocol 包中注册的 Sniffer 在连接建立阶段判断
 // 当前连接是 Thrift 协议还是 HTTP 协议。
 //
 // 实现细节：
+// - 如果启用了 PROXY protocol 且连接以 PROXY v1/v2 头开头，则剥离该头并以其中的真实地址作为 RemoteAddr，再对剩余数据执行以下识别
+// - 如果连接以 TLS ClientHello 开头，则完成握手后对解密的数据重新执行以下识别（优先使用 ALPN 协商结果）
+// - 如果连接以 HTTP/2 连接前言（PRI * HTTP/2.0）开头，则使用 HTTP2Handler（h2c 与 gRPC）
 // - 如果连接首部符合 HTTP 请求行（如 "POST /api/... HTTP/1.1"），则使用我们自定义的 HTTP1Handler
-// - 如果连接是 Thrift 二进制流（如 0x80 开头），则回退为默认 ThriftHandler
+// - 如果连接是 Thrift 二进制流（如 0x80 开头），按帧头识别具体变体：
+//   buffered / framed / TTHeader 使用 Kitex 默认 ThriftHandler，compact / non-strict / THeader 使用 apachethrift
+// - 都无法识别时回退为默认 ThriftHandler
 //
 // ✅ 动态切换发生在 detection 模块内部：
 // --> 代码位置：
@@ -44,9 +56,6 @@ import (
 //
 // ====================================================================
 
-// 简单的HTTP请求行识别
-var httpPattern = regexp.MustCompile(`^(?:GET |POST|PUT|DELE|HEAD|OPTI|CONN|TRAC|PATC)$`)
-
 // NewSvrTransHandlerFactoryWithHTTP ✅ 推荐入口函数：从 main.go 调用
 // - 只需传入 HTTP handler 工厂，Thrift handler 使用 Kitex 默认的 netpoll 实现
 func NewSvrTransHandlerFactoryWithHTTP(
@@ -61,8 +70,151 @@ func NewSvrTransHandlerFactory(
 	thriftHandlerFactory remote.ServerTransHandlerFactory,
 	httpHandlerFactory remote.ServerTransHandlerFactory,
 ) remote.ServerTransHandlerFactory {
-	return detection.NewSvrTransHandlerFactory(
-		thriftHandlerFactory,
-		httpHandlerFactory,
-	)
+	return NewSvrTransHandlerFactoryWithProtocols(thriftHandlerFactory, map[protocol.ProtocolType]remote.ServerTransHandlerFactory{
+		protocol.ProtocolThrift: NewThriftHandlerFactory(thriftHandlerFactory),
+		protocol.ProtocolHttp:   httpHandlerFactory,
+	})
+}
+
+// NewSvrTransHandlerFactoryWithHTTP2 在 HTTP/1 与 Thrift 的基础上增加 h2c（含 gRPC）的嗅探与处理
+func NewSvrTransHandlerFactoryWithHTTP2(
+	httpHandlerFactory remote.ServerTransHandlerFactory,
+	http2HandlerFactory remote.ServerTransHandlerFactory,
+) remote.ServerTransHandlerFactory {
+	return NewSvrTransHandlerFactoryWithTLS(nil, httpHandlerFactory, http2HandlerFactory)
+}
+
+// NewSvrTransHandlerFactoryWithTLS 在 NewSvrTransHandlerFactoryWithHTTP2 的基础上终止 TLS：
+// TLS 连接握手后对解密的数据重新嗅探，HTTPS JSON、TLS 上的 h2/gRPC 与 TLS Thrift 客户端可以共用端口。
+// tlsConfig 为 nil 时不识别 TLS
+func NewSvrTransHandlerFactoryWithTLS(
+	tlsConfig *tls.Config,
+	httpHandlerFactory remote.ServerTransHandlerFactory,
+	http2HandlerFactory remote.ServerTransHandlerFactory,
+) remote.ServerTransHandlerFactory {
+	return NewSvrTransHandlerFactoryWithOptions(httpHandlerFactory, http2HandlerFactory, WithTLS(tlsConfig))
+}
+
+// NewSvrTransHandlerFactoryWithOptions 按 opts 组装完整的识别链：
+// PROXY 头（可选）→ TLS（可选）→ h2c / HTTP/1 / Thrift，每剥离一层都对剩余数据重新嗅探
+func NewSvrTransHandlerFactoryWithOptions(
+	httpHandlerFactory remote.ServerTransHandlerFactory,
+	http2HandlerFactory remote.ServerTransHandlerFactory,
+	opts ...Option,
+) remote.ServerTransHandlerFactory {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	policy := o.sniffPolicy()
+	thriftFactory := netpoll.NewSvrTransHandlerFactory()
+	layer := func() map[protocol.ProtocolType]remote.ServerTransHandlerFactory {
+		handlers := bridgeHandlers(thriftFactory, httpHandlerFactory, http2HandlerFactory)
+		if o.TLSConfig != nil {
+			inner := bridgeHandlers(thriftFactory, httpHandlerFactory, http2HandlerFactory)
+			handlers[protocol.ProtocolTLS] = newTLSHandlerFactory(o.TLSConfig, policy, thriftFactory, inner)
+		}
+		return handlers
+	}
+	handlers := layer()
+	if o.ProxyProtocol {
+		handlers[protocol.ProtocolProxy] = &proxyHandlerFactory{
+			trusted: o.TrustedProxies, policy: policy, defaultFactory: thriftFactory, factories: layer(),
+		}
+	}
+	return newDetectionFactory(thriftFactory, handlers, policy)
+}
+
+func bridgeHandlers(
+	thriftFactory, httpHandlerFactory, http2HandlerFactory remote.ServerTransHandlerFactory,
+) map[protocol.ProtocolType]remote.ServerTransHandlerFactory {
+	return map[protocol.ProtocolType]remote.ServerTransHandlerFactory{
+		protocol.ProtocolThrift: NewThriftHandlerFactory(thriftFactory),
+		protocol.ProtocolHttp:   httpHandlerFactory,
+		protocol.ProtocolHttp2:  http2HandlerFactory,
+	}
+}
+
+// NewSvrTransHandlerFactoryWithProtocols 按 protocol 包中注册的嗅探器构建 detection 链：
+// 嗅探器按优先级依次尝试，命中后交给 handlers 中对应协议的 handler；都未命中时使用 defaultHandlerFactory。
+// 新增协议只需注册一个 protocol.Sniffer，并在 handlers 中提供对应的 handler 工厂。
+// 返回的工厂同时适用于 netpoll 与 gonet 传输层，嗅探使用默认的超时与未知协议策略。
+func NewSvrTransHandlerFactoryWithProtocols(
+	defaultHandlerFactory remote.ServerTransHandlerFactory,
+	handlers map[protocol.ProtocolType]remote.ServerTransHandlerFactory,
+) remote.ServerTransHandlerFactory {
+	return newDetectionFactory(defaultHandlerFactory, handlers, defaultSniffPolicy())
+}
+
+func newDetectionFactory(
+	defaultHandlerFactory remote.ServerTransHandlerFactory,
+	handlers map[protocol.ProtocolType]remote.ServerTransHandlerFactory,
+	policy sniffPolicy,
+) remote.ServerTransHandlerFactory {
+	var detectable []remote.ServerTransHandlerFactory
+	for _, s := range protocol.Sniffers() {
+		f, ok := handlers[s.Protocol]
+		if !ok {
+			continue
+		}
+		detectable = append(detectable, &sniffedHandlerFactory{factory: f, sniffer: s})
+	}
+	for p := range handlers {
+		if _, ok := protocol.LookupSniffer(p); !ok {
+			klog.Warnf("KITEX: no sniffer registered for protocol %s, its handler is ignored", p)
+		}
+	}
+	return &transportAdapterFactory{
+		factory: detection.NewSvrTransHandlerFactory(defaultHandlerFactory, detectable...),
+		policy:  policy,
+	}
+}
+
+// sniffedHandlerFactory 为 handler 附加基于 Sniffer 的 ProtocolMatch
+type sniffedHandlerFactory struct {
+	factory remote.ServerTransHandlerFactory
+	sniffer protocol.Sniffer
+}
+
+func (f *sniffedHandlerFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	h, err := f.factory.NewTransHandler(opt)
+	if err != nil {
+		return nil, err
+	}
+	return &sniffedHandler{ServerTransHandler: h, sniffer: f.sniffer}, nil
 }
+
+// sniffedHandler 用注册的嗅探器实现 detection.DetectableServerTransHandler
+type sniffedHandler struct {
+	remote.ServerTransHandler
+	sniffer protocol.Sniffer
+}
+
+// ProtocolMatch 优先使用 transportAdapter 已完成的有超时嗅探的结果，避免在此处阻塞预读
+func (h *sniffedHandler) ProtocolMatch(ctx context.Context, conn net.Conn) error {
+	if c := adaptedConnFromContext(ctx); c != nil && c.sniffed {
+		if c.result.protocol == h.sniffer.Protocol {
+			return nil
+		}
+		return errProtocolNotMatch
+	}
+	if h.sniffer.MatchConn(conn) {
+		return nil
+	}
+	return errProtocolNotMatch
+}
+
+func (h *sniffedHandler) SetInvokeHandleFunc(inkHdlFunc endpoint.Endpoint) {
+	if s, ok := h.ServerTransHandler.(remote.InvokeHandleFuncSetter); ok {
+		s.SetInvokeHandleFunc(inkHdlFunc)
+	}
+}
+
+func (h *sniffedHandler) GracefulShutdown(ctx context.Context) error {
+	if g, ok := h.ServerTransHandler.(remote.GracefulShutdown); ok {
+		return g.GracefulShutdown(ctx)
+	}
+	return nil
+}
+
+var errProtocolNotMatch = errors.New("protocol not match")
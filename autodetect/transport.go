@@ -0,0 +1,169 @@
+package autodetect
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/netpoll"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// Detect 识别连接上的协议并返回之后应使用的连接：
+// 自带 netpoll.Reader 的连接直接预读，返回原连接；普通 net.Conn 通过 bufio 预读，
+// 返回用 PrependConn 重新注入已读字节的连接，调用方必须改用返回的连接读取。
+// 预读受 opts 中的 SniffTimeout 与 MinSniffBytes 约束，客户端沉默时返回超时错误而不是一直阻塞；
+// netpoll 连接的读超时在嗅探结束后被清除
+func Detect(conn net.Conn, opts ...Option) (protocol.ProtocolType, net.Conn, error) {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	p := o.sniffPolicy()
+	if _, ok := protocol.ConnReader(conn); ok {
+		res := p.sniff(conn, 0)
+		return res.protocol, conn, res.err()
+	}
+	n := protocol.MaxPeek()
+	br := bufio.NewReaderSize(conn, n)
+	restore := setSniffDeadline(conn, p.timeout, 0)
+	peek, err := br.Peek(n)
+	restore()
+	// bufio 可能多读了字节，全部重新注入
+	buffered, _ := br.Peek(br.Buffered())
+	replay := NewPrependConn(conn, append([]byte(nil), buffered...))
+	res := p.classify(peek, err)
+	return res.protocol, replay, res.err()
+}
+
+// transportAdapterFactory 让识别链可以运行在任意 Kitex 传输层上：
+// netpoll 连接原样传递，其它连接（如 gonet 传输层的连接）包装为 bufferedConnection 后再交给内层。
+// 连接的首次 OnRead 在 policy 的超时内完成嗅探，结果通过 ctx 交给 sniffedHandler 的 ProtocolMatch
+type transportAdapterFactory struct {
+	factory remote.ServerTransHandlerFactory
+	policy  sniffPolicy
+}
+
+func (f *transportAdapterFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	h, err := f.factory.NewTransHandler(opt)
+	if err != nil {
+		return nil, err
+	}
+	return &transportAdapter{ServerTransHandler: h, policy: f.policy, rwTimeout: opt.ReadWriteTimeout}, nil
+}
+
+type transportAdapter struct {
+	remote.ServerTransHandler
+	policy    sniffPolicy
+	rwTimeout time.Duration
+}
+
+// adaptedConn 是连接级的状态：包装后的连接与首次嗅探的结果
+type adaptedConn struct {
+	conn net.Conn
+	// state 为 connIdle 时连接尚未开始读取，timer 到期后把它置为 connExpired 并关闭连接
+	state   int32
+	timer   *time.Timer
+	sniffed bool
+	result  sniffResult
+}
+
+const (
+	connIdle int32 = iota
+	connReading
+	connExpired
+)
+
+type adaptedConnKey struct{}
+
+func adaptedConnFromContext(ctx context.Context) *adaptedConn {
+	c, _ := ctx.Value(adaptedConnKey{}).(*adaptedConn)
+	return c
+}
+
+// conn 返回 OnActive 时包装的连接，netpoll 连接未被包装时返回 conn 本身
+func (h *transportAdapter) conn(ctx context.Context, conn net.Conn) net.Conn {
+	if c := adaptedConnFromContext(ctx); c != nil {
+		return c.conn
+	}
+	return conn
+}
+
+func (h *transportAdapter) OnActive(ctx context.Context, conn net.Conn) (context.Context, error) {
+	if _, ok := conn.(netpoll.Connection); !ok {
+		// gonet 的连接在 OnActive 之前尚未读取数据，可以直接从原始连接读取，
+		// 避免其 Read 必须读满缓冲区才返回
+		raw := conn
+		if rc, ok := conn.(interface{ RawConn() net.Conn }); ok {
+			raw = rc.RawConn()
+		}
+		conn = newBufferedConnection(raw, raw)
+	}
+	c := &adaptedConn{conn: conn}
+	// netpoll 只在数据到达后才调用 OnRead，沉默的连接需要单独计时关闭
+	c.timer = time.AfterFunc(h.policy.timeout, func() {
+		if atomic.CompareAndSwapInt32(&c.state, connIdle, connExpired) {
+			countOutcome(OutcomeTimeout)
+			_ = c.conn.Close()
+		}
+	})
+	ctx = context.WithValue(ctx, adaptedConnKey{}, c)
+	return h.ServerTransHandler.OnActive(ctx, conn)
+}
+
+// OnRead 在连接的首次读取时完成有超时的嗅探，未识别或不被访问规则允许的连接在此被拒绝
+func (h *transportAdapter) OnRead(ctx context.Context, conn net.Conn) error {
+	c := adaptedConnFromContext(ctx)
+	if c == nil {
+		return h.ServerTransHandler.OnRead(ctx, conn)
+	}
+	if !c.sniffed {
+		if !atomic.CompareAndSwapInt32(&c.state, connIdle, connReading) {
+			return ErrSniffTimeout
+		}
+		c.timer.Stop()
+		c.sniffed = true
+		c.result = h.policy.sniff(c.conn, h.rwTimeout)
+		if err := h.policy.admit(ctx, c.conn, c.result, h.rwTimeout); err != nil {
+			return err
+		}
+	}
+	return h.ServerTransHandler.OnRead(ctx, c.conn)
+}
+
+func (h *transportAdapter) Read(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return h.ServerTransHandler.Read(ctx, h.conn(ctx, conn), msg)
+}
+
+func (h *transportAdapter) Write(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return h.ServerTransHandler.Write(ctx, h.conn(ctx, conn), msg)
+}
+
+func (h *transportAdapter) OnInactive(ctx context.Context, conn net.Conn) {
+	if c := adaptedConnFromContext(ctx); c != nil {
+		c.timer.Stop()
+	}
+	h.ServerTransHandler.OnInactive(ctx, h.conn(ctx, conn))
+}
+
+func (h *transportAdapter) OnError(ctx context.Context, err error, conn net.Conn) {
+	h.ServerTransHandler.OnError(ctx, err, h.conn(ctx, conn))
+}
+
+func (h *transportAdapter) SetInvokeHandleFunc(inkHdlFunc endpoint.Endpoint) {
+	if s, ok := h.ServerTransHandler.(remote.InvokeHandleFuncSetter); ok {
+		s.SetInvokeHandleFunc(inkHdlFunc)
+	}
+}
+
+func (h *transportAdapter) GracefulShutdown(ctx context.Context) error {
+	if g, ok := h.ServerTransHandler.(remote.GracefulShutdown); ok {
+		return g.GracefulShutdown(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package autodetect
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/remote/trans/gonet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+func TestDetect_PlainConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go fmt.Fprint(client, "GET /api/STService/testSTReq HTTP/1.1\r\n\r\n")
+
+	p, conn, err := Detect(server)
+	require.NoError(t, err)
+	assert.Equal(t, protocol.ProtocolHttp, p)
+	// 预读的字节通过 PrependConn 重新注入
+	line := make([]byte, 4)
+	_, err = io.ReadFull(conn, line)
+	require.NoError(t, err)
+	assert.Equal(t, "GET ", string(line))
+}
+
+func TestGonetTransport(t *testing.T) {
+	cert, pool := selfSignedCert(t)
+	addr := startServerOn(t, gonet.NewTransServerFactory(), WithTLS(&tls.Config{Certificates: []tls.Certificate{cert}}))
+	body := []byte(`{"Name":"bob"}`)
+	want := `{"code":200,"message":"success","data":{"name":"bob"}}`
+
+	t.Run("http1 keep-alive", func(t *testing.T) {
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		defer conn.Close()
+		const req = "POST /api/STService/testSTReq HTTP/1.1\r\nContent-Length: %d\r\n%s\r\n%s"
+		fmt.Fprintf(conn, req, len(body), "", body)
+		fmt.Fprintf(conn, req, len(body), "Connection: close\r\n", body)
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		out, _ := io.ReadAll(conn)
+		assert.Equal(t, 2, bytes.Count(out, []byte(want)), string(out))
+	})
+
+	t.Run("h2c", func(t *testing.T) {
+		cli := &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS:   func(network, addr string, _ *tls.Config) (net.Conn, error) { return net.Dial(network, addr) },
+		}}
+		resp, err := cli.Post("http://"+addr+"/api/STService/testSTReq", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, want, string(out))
+	})
+
+	t.Run("https", func(t *testing.T) {
+		cli := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+		resp, err := cli.Post("https://"+addr+"/api/STService/testSTReq", "application/json", bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, want, string(out))
+	})
+
+	t.Run("thrift", func(t *testing.T) {
+		for name, pf := range map[string]thrift.TProtocolFactory{
+			"buffered": thrift.NewTBinaryProtocolFactoryDefault(),
+			"compact":  thrift.NewTCompactProtocolFactory(),
+		} {
+			sock, err := thrift.NewTSocket(addr)
+			require.NoError(t, err)
+			require.NoError(t, sock.Open())
+			cli := stability.NewSTServiceClientFactory(sock, pf)
+			for i := 0; i < 2; i++ {
+				n := name
+				resp, err := cli.TestSTReq(context.Background(), &stability.STRequest{Name: &n})
+				require.NoError(t, err, name)
+				assert.Equal(t, name, resp.GetName())
+			}
+			sock.Close()
+		}
+	})
+}
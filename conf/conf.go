@@ -23,6 +23,12 @@ type Config struct {
 	MySQL    MySQL    `yaml:"mysql"`
 	Redis    Redis    `yaml:"redis"`
 	Registry Registry `yaml:"registry"`
+	HTTP     HTTP     `yaml:"http"`
+	TLS      TLS      `yaml:"tls"`
+
+	ProxyProtocol ProxyProtocol `yaml:"proxy_protocol"`
+	Detection     Detection     `yaml:"detection"`
+	AccessRules   []AccessRule  `yaml:"access_rules"`
 }
 
 type MySQL struct {
@@ -52,6 +58,85 @@ type Registry struct {
 	Password        string   `yaml:"password"`
 }
 
+// TLS configures TLS termination on the service port, disabled when CertFile is empty
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// ProxyProtocol configures HAProxy PROXY v1/v2 headers sent by an L4 load balancer
+type ProxyProtocol struct {
+	Enabled bool `yaml:"enabled"`
+	// TrustedCIDRs limits which peers may send PROXY headers, required when Enabled
+	TrustedCIDRs []string `yaml:"trusted_cidrs"`
+}
+
+// Detection configures how long protocol sniffing waits and what happens to unrecognized connections
+type Detection struct {
+	// SniffTimeout is a Go duration such as "10s", empty means the default
+	SniffTimeout  string `yaml:"sniff_timeout"`
+	MinSniffBytes int    `yaml:"min_sniff_bytes"`
+	// UnknownAction is one of "thrift" (default), "close" or "http400"
+	UnknownAction string `yaml:"unknown_action"`
+}
+
+// AccessRule restricts protocols (sniffer names such as "thrift", "http1", "h2c") to the given
+// listener addresses ("ip:port" or ":port") and client CIDRs; empty lists match anything
+type AccessRule struct {
+	Protocols []string `yaml:"protocols"`
+	Listeners []string `yaml:"listeners"`
+	Sources   []string `yaml:"sources"`
+}
+
+// HTTP configures the HTTP bridge
+type HTTP struct {
+	// IDL lists thrift files whose api.* method annotations are loaded as routes
+	IDL []string `yaml:"idl"`
+	// IDLReloadInterval is a Go duration such as "5s"; when set, the idl files above and the
+	// generic services' idl files are re-parsed on change, empty disables hot reload
+	IDLReloadInterval string `yaml:"idl_reload_interval"`
+	// Validate checks request args against the vt.* annotations in the idl files above
+	Validate bool        `yaml:"validate"`
+	Routes   []HTTPRoute `yaml:"routes"`
+	JSON     HTTPJSON    `yaml:"json"`
+	// WebSocket enables the WebSocket bridge on the given path, empty disables it
+	WebSocket HTTPWebSocket `yaml:"websocket"`
+	// Generic forwards services described by thrift IDL to downstream servers without generated code
+	Generic []HTTPGeneric `yaml:"generic"`
+}
+
+// HTTPGeneric is a downstream thrift service called through IDL-driven generic calls
+type HTTPGeneric struct {
+	IDL         string   `yaml:"idl"`
+	IncludeDirs []string `yaml:"include_dirs"`
+	// Service is the service name in the IDL, empty means the last service of the file
+	Service   string   `yaml:"service"`
+	Addresses []string `yaml:"addresses"`
+}
+
+// HTTPWebSocket configures the WebSocket bridge
+type HTTPWebSocket struct {
+	Path string `yaml:"path"`
+	// PingInterval is a Go duration such as "30s", empty means the default
+	PingInterval string `yaml:"ping_interval"`
+}
+
+// HTTPJSON configures how enums, i64, binary and sets are represented in JSON
+type HTTPJSON struct {
+	EnumAsName    bool `yaml:"enum_as_name"`
+	Int64AsString bool `yaml:"int64_as_string"`
+	BinaryAsHex   bool `yaml:"binary_as_hex"`
+	UniqueSets    bool `yaml:"unique_sets"`
+}
+
+// HTTPRoute maps an HTTP method and path template to a Kitex service method
+type HTTPRoute struct {
+	Method  string `yaml:"method"`
+	Path    string `yaml:"path"`
+	Service string `yaml:"service"`
+	RPC     string `yaml:"rpc"`
+}
+
 // GetConf gets configuration instance
 func GetConf() *Config {
 	once.Do(initConf)
@@ -0,0 +1,152 @@
+package http1
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// Hertz 风格的字段级 api.* 注解，与 go.tag 中的 query、header 等 tag 作用相同，例如：
+//
+//	struct GetUserRequest {
+//	    1: i64 id (api.path = "id")
+//	    2: string token (api.header = "X-Token")
+//	    3: list<string> fields (api.query = "fields")
+//	    4: string nickName (api.body = "nick_name")
+//	    5: binary payload (api.raw_body = "")
+//	}
+var apiFieldAnnotations = map[string]string{
+	"api.query":  "query",
+	"api.header": "header",
+	"api.cookie": "cookie",
+	"api.path":   "path",
+	"api.form":   "form",
+}
+
+// apiBinding 是一个字段上 api.* 注解声明的参数来源
+type apiBinding struct {
+	// sources 是 query、header、cookie、path、form 来源及参数名，按注解顺序查找
+	sources []apiSource
+	// body 是 api.body 声明的 JSON Body 键名
+	body string
+	// rawBody 为 true 时字段取原始请求 Body
+	rawBody bool
+}
+
+type apiSource struct {
+	source, key string
+}
+
+// apiBindings 按 IDL struct 对应的生成类型与字段 ID 索引字段的 api.* 注解，字段按 thrift 字段 ID 与生成代码对应
+type apiBindings map[structKey]map[int]*apiBinding
+
+// bindingsFromAST 收集 IDL 及其 include 中所有 struct 字段的 api.* 注解
+func bindingsFromAST(ast *parser.Thrift) apiBindings {
+	structs := make(apiBindings)
+	for t := range ast.DepthFirstSearch() {
+		for _, st := range append(t.Structs, t.Unions...) {
+			fields := make(map[int]*apiBinding)
+			for _, f := range st.Fields {
+				b := &apiBinding{}
+				for _, anno := range f.Annotations {
+					key := strings.ToLower(anno.Key)
+					value := f.Name
+					if len(anno.Values) > 0 && anno.Values[0] != "" {
+						value = anno.Values[0]
+					}
+					if source, ok := apiFieldAnnotations[key]; ok {
+						b.sources = append(b.sources, apiSource{source: source, key: value})
+					} else if key == "api.body" {
+						b.body = value
+					} else if key == "api.raw_body" {
+						b.rawBody = true
+					}
+				}
+				if len(b.sources) > 0 || b.body != "" || b.rawBody {
+					fields[int(f.ID)] = b
+				}
+			}
+			if len(fields) > 0 {
+				structs[newStructKey(t, st)] = fields
+			}
+		}
+	}
+	return structs
+}
+
+// bindAPI 按字段的 api.* 注解取值，返回是否已赋值；声明了注解的字段不再按 struct tag 查找
+func (s *bindingSource) bindAPI(fv reflect.Value, sf reflect.StructField, b *apiBinding) (bool, error) {
+	if b.rawBody {
+		if len(s.body) == 0 {
+			return false, nil
+		}
+		if err := setFieldValues(fv, []string{string(s.body)}); err != nil {
+			return false, &BindError{Field: sf.Name, Source: "raw_body", Key: "", Err: err}
+		}
+		return true, nil
+	}
+	for _, src := range b.sources {
+		if values := s.lookup(src.source, src.key); len(values) > 0 {
+			if err := setSourceValues(fv, src.source, values); err != nil {
+				return false, &BindError{Field: sf.Name, Source: src.source, Key: src.key, Err: err}
+			}
+			return true, nil
+		}
+	}
+	if b.body != "" {
+		raw, ok := s.bodyJSON()[b.body]
+		if !ok {
+			return false, nil
+		}
+		if err := (jsonAssigner{conv: s.conv}).assign(fv, raw, b.body); err != nil {
+			return false, &BindError{Field: sf.Name, Source: "body", Key: b.body, Err: err}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// bodyJSON 惰性解析 JSON Body 的顶层对象，Body 不是 JSON 对象时返回 nil
+func (s *bindingSource) bodyJSON() map[string]interface{} {
+	if s.bodyObject == nil && len(s.body) > 0 && !isFormBody(s.contentType) {
+		dec := json.NewDecoder(bytes.NewReader(s.body))
+		dec.UseNumber()
+		obj := map[string]interface{}{}
+		if err := dec.Decode(&obj); err != nil {
+			obj = map[string]interface{}{}
+		}
+		s.bodyObject = obj
+	}
+	return s.bodyObject
+}
+
+// hasRawBody 判断 Args 或其参数 struct 中是否有 api.raw_body 字段，这类方法的 Body 不一定是 JSON
+func (b apiBindings) hasRawBody(args interface{}) bool {
+	t := reflect.TypeOf(args)
+	for depth := 0; depth < 2 && t != nil; depth++ {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return false
+		}
+		var next reflect.Type
+		fields := lookupStruct(b, t)
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if id, ok := thriftFieldID(sf); ok {
+				if f := fields[id]; f != nil && f.rawBody {
+					return true
+				}
+			}
+			if ft := sf.Type; ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct {
+				next = ft
+			}
+		}
+		t = next
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package http1
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+)
+
+// annotatedIDL 在 stability.thrift 的 STRequest 字段上加上 api.* 注解，并把路由改为带路径变量的形式
+func annotatedIDL(t *testing.T) string {
+	raw, err := os.ReadFile("../idl/stability.thrift")
+	require.NoError(t, err)
+	idl := strings.NewReplacer(
+		"1: optional string Name", `1: optional string Name (api.path = "name", api.header = "X-Name")`,
+		"5: optional i32 int32", `5: optional i32 int32 (api.query = "n")`,
+		"8: optional string str", `8: optional string str (api.body = "s")`,
+		"9: optional binary bin", `9: optional binary bin (api.raw_body = "")`,
+		"14: optional string flagMsg", `14: optional string flagMsg (api.cookie = "flag")`,
+		`(api.post = "/v1/stability/st")`, `(api.post = "/v1/st/:name", api.get = "/v1/st")`,
+	).Replace(string(raw))
+	path := filepath.Join(t.TempDir(), "stability.thrift")
+	require.NoError(t, os.WriteFile(path, []byte(idl), 0o644))
+	return path
+}
+
+func TestBindAPI(t *testing.T) {
+	ast, err := parser.ParseFile(annotatedIDL(t), nil, true)
+	require.NoError(t, err)
+	bindings := bindingsFromAST(ast)
+	assert.Equal(t, []apiSource{{source: "path", key: "name"}, {source: "header", key: "X-Name"}},
+		bindings[structKey{pkg: "thrift/stability", name: "STRequest"}][1].sources)
+
+	args := &stability.STServiceTestSTReqArgs{}
+	assert.True(t, bindings.hasRawBody(args))
+
+	src := newBindingSource(&request{
+		rawQuery: "n=7",
+		headers:  map[string]string{"X-Name": "bob", "Cookie": "flag=on"},
+		body:     []byte(`{"s":"x"}`),
+	})
+	src.api = bindings
+	require.NoError(t, bindArgs(args, src))
+	assert.Equal(t, "bob", args.Req.GetName())
+	assert.Equal(t, int32(7), args.Req.GetInt32())
+	assert.Equal(t, "x", args.Req.GetStr())
+	assert.Equal(t, "on", args.Req.GetFlagMsg())
+	assert.Equal(t, []byte(`{"s":"x"}`), args.Req.GetBin())
+
+	src.query.Set("n", "abc")
+	var bErr *BindError
+	require.ErrorAs(t, bindArgs(args, src), &bErr)
+	assert.Equal(t, "query", bErr.Source)
+}
+
+func TestAPIAnnotations_HTTP(t *testing.T) {
+	addr := startServer(t, WithIDLRoutes(annotatedIDL(t)))
+	call := func(req *http.Request) (int, map[string]interface{}) {
+		status, out := doRequest(t, req)
+		data, _ := out["data"].(map[string]interface{})
+		return status, data
+	}
+
+	// 路径变量优先于 Header
+	req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/v1/st/alice", strings.NewReader(`{"s":"x"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Name", "bob")
+	status, data := call(req)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "alice", data["name"])
+
+	req, err = http.NewRequest(http.MethodGet, "http://"+addr+"/v1/st", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Name", "bob")
+	status, data = call(req)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "bob", data["name"])
+
+	// 带 api.raw_body 字段的方法接受非 JSON 的 Body
+	req, err = http.NewRequest(http.MethodPost, "http://"+addr+"/v1/st/carol", strings.NewReader("plain text"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "text/plain")
+	status, data = call(req)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "carol", data["name"])
+}
@@ -0,0 +1,315 @@
+package http1
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 参数来源对应的 struct tag，按优先级排列：同一字段声明了多个来源时取第一个有值的来源
+var bindingTags = []string{"path", "form", "query", "cookie", "header"}
+
+// 绑定时最多向下展开的结构体层数：args → 请求结构体
+const maxBindDepth = 2
+
+// BindError 表示某个字段无法从 HTTP 参数转换为目标类型
+type BindError struct {
+	Field  string // Go 字段名
+	Source string // 参数来源，如 query、header
+	Key    string // 参数名
+	Err    error
+}
+
+func (e *BindError) Error() string {
+	return fmt.Sprintf("bind %s %q to field %s failed: %v", e.Source, e.Key, e.Field, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// bindingSource 汇总一次请求中可用于绑定的参数来源
+type bindingSource struct {
+	query   url.Values
+	form    url.Values // 表单 Body（urlencoded 或 multipart）中的字段，由 parseForm 解析
+	path    map[string]string
+	cookies map[string]string
+	headers map[string]string
+	// queryFallback 为 true 时，没有来源 tag 的字段按 JSON 字段名从 Query 中取值；
+	// 表单 Body 中的字段总是可以按 JSON 字段名绑定到没有来源 tag 的字段
+	queryFallback bool
+	// api 是 IDL 中字段级 api.* 注解声明的来源，优先于 struct tag
+	api apiBindings
+	// body 供 api.body 与 api.raw_body 使用
+	body        []byte
+	contentType string
+	bodyObject  map[string]interface{}
+	conv        JSONConventions
+}
+
+func newBindingSource(req *request) *bindingSource {
+	src := &bindingSource{
+		headers:     req.headers,
+		path:        req.pathParams,
+		cookies:     parseCookies(headerValue(req.headers, "Cookie")),
+		body:        req.body,
+		contentType: headerValue(req.headers, "Content-Type"),
+	}
+	src.query, _ = url.ParseQuery(req.rawQuery)
+	return src
+}
+
+// lookup 按来源查找参数值，返回值为空表示该来源没有此参数
+func (s *bindingSource) lookup(source, key string) []string {
+	switch source {
+	case "query":
+		return s.query[key]
+	case "form":
+		return s.form[key]
+	case "path":
+		if v, ok := s.path[key]; ok {
+			return []string{v}
+		}
+	case "cookie":
+		if v, ok := s.cookies[key]; ok {
+			return []string{v}
+		}
+	case "header":
+		if v := headerValue(s.headers, key); v != "" {
+			return []string{v}
+		}
+	}
+	return nil
+}
+
+// bindArgs 根据 struct tag（query、header、cookie、path、form）或 IDL 中的 api.* 注解把 HTTP 参数填入 Thrift 参数结构体。
+// args 一般是 kitex_gen 生成的 XxxArgs，tag 声明在其内部的请求结构体上，例如：
+//
+//	Framework *string `thrift:"framework,16,optional" query:"framework"`
+//	UserId    *string `thrift:"userId,17,optional" header:"X-User-Id"`
+//
+// 绑定在 JSON Body 解析之后执行，请求中存在的参数会覆盖 Body 中的同名字段。
+func bindArgs(args interface{}, src *bindingSource) error {
+	v := reflect.ValueOf(args)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	_, err := bindStruct(v.Elem(), src, 1)
+	return err
+}
+
+// bindStruct 绑定结构体中带来源 tag 的字段，返回是否有字段被赋值
+func bindStruct(v reflect.Value, src *bindingSource, depth int) (bool, error) {
+	bound := false
+	t := v.Type()
+	fields := lookupStruct(src.api, t)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		fv := v.Field(i)
+		if id, ok := thriftFieldID(sf); ok && fields[id] != nil {
+			ok, err := src.bindAPI(fv, sf, fields[id])
+			if err != nil {
+				return bound, err
+			}
+			bound = bound || ok
+			continue
+		}
+		if source, key, values := lookupField(sf, src); values != nil {
+			if err := setSourceValues(fv, source, values); err != nil {
+				return bound, &BindError{Field: sf.Name, Source: source, Key: key, Err: err}
+			}
+			bound = true
+			continue
+		}
+		if depth >= maxBindDepth {
+			continue
+		}
+		ok, err := bindNested(fv, src, depth+1)
+		if err != nil {
+			return bound, err
+		}
+		bound = bound || ok
+	}
+	return bound, nil
+}
+
+// bindNested 展开结构体字段继续绑定；指针为 nil 时只有确实绑定到值才会分配
+func bindNested(fv reflect.Value, src *bindingSource, depth int) (bool, error) {
+	switch {
+	case fv.Kind() == reflect.Struct:
+		return bindStruct(fv, src, depth)
+	case fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct:
+		if !fv.IsNil() {
+			return bindStruct(fv.Elem(), src, depth)
+		}
+		nv := reflect.New(fv.Type().Elem())
+		ok, err := bindStruct(nv.Elem(), src, depth)
+		if ok && err == nil {
+			fv.Set(nv)
+		}
+		return ok, err
+	}
+	return false, nil
+}
+
+func lookupField(sf reflect.StructField, src *bindingSource) (source, key string, values []string) {
+	tagged := false
+	for _, source := range bindingTags {
+		key, ok := sf.Tag.Lookup(source)
+		if !ok {
+			continue
+		}
+		key = strings.Split(key, ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		tagged = true
+		if values := src.lookup(source, key); len(values) > 0 {
+			return source, key, values
+		}
+	}
+	// 已声明来源 tag 的字段只从声明的来源取值
+	if !tagged && isBindableType(sf.Type) {
+		if name, _, ok := jsonFieldName(sf); ok {
+			if values := src.lookup("form", name); len(values) > 0 {
+				return "form", name, values
+			}
+			if values := src.lookup("query", name); len(values) > 0 && src.queryFallback {
+				return "query", name, values
+			}
+		}
+	}
+	return "", "", nil
+}
+
+// isBindableType 判断字段能否由字符串参数直接转换得到（基础类型、枚举及其列表）
+func isBindableType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// setFieldValues 把字符串参数转换为字段类型：支持指针、基础类型、枚举以及 list/set
+func setFieldValues(fv reflect.Value, values []string) error {
+	return setValues(fv, values, true)
+}
+
+// setFormValues 与 setFieldValues 相同，但表单 Body 中的值（可能是上传的文件内容）原样使用，列表只由同名的多个字段组成
+func setFormValues(fv reflect.Value, values []string) error {
+	return setValues(fv, values, false)
+}
+
+// setSourceValues 按参数来源选择 setFormValues 或 setFieldValues
+func setSourceValues(fv reflect.Value, source string, values []string) error {
+	if source == "form" {
+		return setFormValues(fv, values)
+	}
+	return setFieldValues(fv, values)
+}
+
+func setValues(fv reflect.Value, values []string, split bool) error {
+	if fv.Kind() == reflect.Ptr {
+		nv := reflect.New(fv.Type().Elem())
+		if err := setValues(nv.Elem(), values, split); err != nil {
+			return err
+		}
+		fv.Set(nv)
+		return nil
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		// 重复参数（?a=1&a=2）或逗号分隔的单个值（a=1,2）都可以作为列表；list<binary> 的元素是原始字节，不拆分也不去除空白
+		elem := fv.Type().Elem()
+		split = split && !(elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.Uint8)
+		if split && len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		list := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, s := range values {
+			if split {
+				s = strings.TrimSpace(s)
+			}
+			if err := setValues(list.Index(i), []string{s}, split); err != nil {
+				return err
+			}
+		}
+		fv.Set(list)
+		return nil
+	}
+	return setScalar(fv, values[0])
+}
+
+func setScalar(fv reflect.Value, s string) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Slice:
+		// binary 字段直接使用原始字节
+		fv.SetBytes([]byte(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			// 数值解析失败时尝试按枚举名称解析
+			if ev, ok, enumErr := parseEnumName(fv.Type(), s); ok {
+				if enumErr != nil {
+					return enumErr
+				}
+				fv.SetInt(ev)
+				return nil
+			}
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
+
+// parseCookies 解析 Cookie 头：name1=value1; name2=value2
+func parseCookies(header string) map[string]string {
+	if header == "" {
+		return nil
+	}
+	cookies := make(map[string]string)
+	for _, part := range strings.Split(header, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || name == "" {
+			continue
+		}
+		cookies[name] = strings.Trim(value, `"`)
+	}
+	return cookies
+}
+
+func isFormURLEncoded(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), "application/x-www-form-urlencoded")
+}
@@ -0,0 +1,87 @@
+package http1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+)
+
+type bindTarget struct {
+	ID     int64               `path:"id"`
+	Flags  []int32             `query:"flag"`
+	On     *bool               `query:"on"`
+	Ratio  float64             `form:"ratio"`
+	Enum   *stability.TestEnum `query:"e"`
+	Token  string              `cookie:"token"`
+	Trace  string              `header:"X-Trace-Id"`
+	Ignore string
+}
+
+func TestBindArgs(t *testing.T) {
+	RegisterEnum(stability.TestEnumFromString)
+	req := &request{
+		rawQuery: "flag=1&flag=2&on=true&e=THIRD",
+		headers: map[string]string{
+			"x-trace-id":   "t-1",
+			"Cookie":       `token="abc"; other=1`,
+			"Content-Type": "application/x-www-form-urlencoded; charset=utf-8",
+		},
+		body: []byte("ratio=0.5"),
+	}
+	src := newBindingSource(req)
+	src.path = map[string]string{"id": "42"}
+	var err error
+	src.form, err = parseForm(src.contentType, req.body, defaultMaxBodySize)
+	assert.NoError(t, err)
+
+	target := &bindTarget{Ignore: "keep"}
+	assert.NoError(t, bindArgs(target, src))
+	assert.Equal(t, int64(42), target.ID)
+	assert.Equal(t, []int32{1, 2}, target.Flags)
+	assert.True(t, *target.On)
+	assert.Equal(t, 0.5, target.Ratio)
+	assert.Equal(t, stability.TestEnum_THIRD, *target.Enum)
+	assert.Equal(t, "abc", target.Token)
+	assert.Equal(t, "t-1", target.Trace)
+	assert.Equal(t, "keep", target.Ignore)
+}
+
+func TestBindArgs_GeneratedArgs(t *testing.T) {
+	req := &request{
+		rawQuery: "framework=kitex",
+		headers:  map[string]string{"X-User-Id": "u-1"},
+	}
+	args := stability.NewSTServiceTestSTReqArgs()
+	assert.NoError(t, bindArgs(args, newBindingSource(req)))
+	assert.Equal(t, "kitex", args.Req.GetFramework())
+	assert.Equal(t, "u-1", args.Req.GetUserId())
+
+	// 没有任何参数命中时不分配请求结构体
+	args = stability.NewSTServiceTestSTReqArgs()
+	assert.NoError(t, bindArgs(args, newBindingSource(&request{})))
+	assert.Nil(t, args.Req)
+}
+
+func TestBindArgs_ConvertError(t *testing.T) {
+	src := newBindingSource(&request{rawQuery: "on=maybe"})
+	err := bindArgs(&bindTarget{}, src)
+	var bindErr *BindError
+	assert.ErrorAs(t, err, &bindErr)
+	assert.Equal(t, "query", bindErr.Source)
+	assert.Equal(t, "on", bindErr.Key)
+}
+
+func TestBindArgs_QueryFallback(t *testing.T) {
+	src := newBindingSource(&request{rawQuery: "Name=kitex&int32=7&e=2&stringList=a,b&userId=ignored"})
+	src.queryFallback = true
+	args := stability.NewSTServiceTestSTReqArgs()
+	assert.NoError(t, bindArgs(args, src))
+	assert.Equal(t, "kitex", args.Req.GetName())
+	assert.Equal(t, int32(7), args.Req.GetInt32())
+	assert.Equal(t, stability.TestEnum_SECOND, args.Req.GetE())
+	assert.Equal(t, []string{"a", "b"}, args.Req.StringList)
+	// userId 声明了 header tag，不会从 query 中取值
+	assert.Nil(t, args.Req.UserId)
+}
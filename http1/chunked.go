@@ -0,0 +1,133 @@
+package http1
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/netpoll"
+)
+
+var (
+	ErrInvalidChunk              = errors.New("invalid chunked transfer encoding")
+	ErrBodyTooLarge              = errors.New("request body too large")
+	ErrUnsupportedTransferCoding = errors.New("unsupported Transfer-Encoding")
+)
+
+// isChunked 判断 Transfer-Encoding 是否以 chunked 结尾；
+// 存在其它编码（如 gzip）时无法解码，返回 ErrUnsupportedTransferCoding
+func isChunked(transferEncoding string) (bool, error) {
+	if transferEncoding == "" {
+		return false, nil
+	}
+	codings := strings.Split(transferEncoding, ",")
+	for i, c := range codings {
+		c = strings.TrimSpace(c)
+		if strings.EqualFold(c, "chunked") && i == len(codings)-1 {
+			return true, nil
+		}
+		if !strings.EqualFold(c, "identity") {
+			return false, ErrUnsupportedTransferCoding
+		}
+	}
+	return false, nil
+}
+
+// readChunkedBody 解码 chunked 请求体，格式为：
+//
+//	chunk-size [; chunk-ext] CRLF
+//	chunk-data CRLF
+//	...
+//	0 [; chunk-ext] CRLF
+//	*( trailer-field CRLF )
+//	CRLF
+//
+// chunk 扩展会被忽略，trailer 以 Header 形式返回；解码后的总长度超过 maxSize 时返回 ErrBodyTooLarge，
+// chunk 长度行与 trailer 的总长度受 maxHeader 限制
+func readChunkedBody(reader netpoll.Reader, maxSize, maxHeader int) (body []byte, trailers map[string]string, err error) {
+	cr := &chunkedReader{r: reader, max: maxSize, maxHeader: maxHeader}
+	if body, err = io.ReadAll(cr); err != nil {
+		return nil, nil, err
+	}
+	return body, cr.trailers, nil
+}
+
+// chunkedReader 以流的方式解码 chunked 请求体，读完结尾的 0 长度 chunk 与 trailer 后返回 io.EOF；
+// 已读取的数据立即从 reader 中释放，不会在连接缓冲区中累积整个 Body
+type chunkedReader struct {
+	r         netpoll.Reader
+	max       int
+	maxHeader int
+	// left 是当前 chunk 剩余的字节数，size 是已解码的总长度
+	left, size int64
+	started    bool
+	trailers   map[string]string
+	// err 是第一个读取错误，之后的 Read 都返回它
+	err error
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.left == 0 {
+		if c.err = c.nextChunk(); c.err != nil {
+			return 0, c.err
+		}
+	}
+	n := int64(len(p))
+	if c.left < n {
+		n = c.left
+	}
+	if avail := int64(c.r.Len()); avail > 0 && avail < n {
+		n = avail
+	}
+	chunk, err := c.r.Next(int(n))
+	if err != nil {
+		c.err = err
+		return 0, err
+	}
+	copy(p, chunk)
+	c.left -= n
+	return int(n), c.r.Release()
+}
+
+// nextChunk 读取下一个 chunk 的长度行，遇到最后一个 chunk 时读取 trailer 并返回 io.EOF
+func (c *chunkedReader) nextChunk() error {
+	if c.started {
+		// 每个 chunk-data 之后必须紧跟 CRLF
+		crlf, err := c.r.Next(2)
+		if err != nil {
+			return err
+		}
+		if crlf[0] != '\r' || crlf[1] != '\n' {
+			return ErrInvalidChunk
+		}
+	}
+	c.started = true
+	line, err := readLine(c.r, c.maxHeader)
+	if err != nil {
+		return err
+	}
+	if i := bytes.IndexByte(line, ';'); i >= 0 {
+		line = line[:i]
+	}
+	size, err := strconv.ParseInt(string(bytes.TrimSpace(line)), 16, 64)
+	if err != nil || size < 0 {
+		return ErrInvalidChunk
+	}
+	if size == 0 {
+		if c.trailers, _, err = parseHeaders(c.r, c.maxHeader); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	if c.size+size > int64(c.max) {
+		return ErrBodyTooLarge
+	}
+	c.size += size
+	c.left = size
+	return nil
+}
@@ -0,0 +1,48 @@
+package http1
+
+import (
+	"context"
+	"net"
+
+	"github.com/cloudwego/netpoll"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+type connStateKey struct{}
+
+// connState 保存单个 HTTP 连接上跨请求复用的状态
+type connState struct {
+	// reader 在整个连接生命周期内复用，避免每次请求新建 bufio 导致已缓冲字节丢失
+	reader netpoll.Reader
+	// writer 用于写出响应，流式响应时可以分块刷新
+	writer netpoll.Writer
+	// req 是当前正在处理的请求，Write 依据它决定 Connection 头
+	req *request
+}
+
+// newConnState 优先复用连接自带的 Reader/Writer（netpoll 与 Kitex gonet 传输层的连接都带有 Reader），
+// 普通 net.Conn 则包装一个新的缓冲 Reader
+func newConnState(conn net.Conn) *connState {
+	cs := &connState{}
+	if r, ok := protocol.ConnReader(conn); ok {
+		cs.reader = r
+	} else {
+		cs.reader = netpoll.NewReader(conn)
+	}
+	if c, ok := conn.(netpoll.Connection); ok {
+		cs.writer = c.Writer()
+	} else {
+		cs.writer = netpoll.NewWriter(conn)
+	}
+	return cs
+}
+
+func withConnState(ctx context.Context, cs *connState) context.Context {
+	return context.WithValue(ctx, connStateKey{}, cs)
+}
+
+func connStateFromContext(ctx context.Context) *connState {
+	cs, _ := ctx.Value(connStateKey{}).(*connState)
+	return cs
+}
@@ -0,0 +1,88 @@
+package http1
+
+import (
+	"context"
+	"reflect"
+)
+
+// ResponseEncoder 决定响应 Body 的结构：返回值会被增量编码为 JSON 写回客户端。
+// 通过 WithResponseEncoder 全局替换，或通过 WithMethodResponseEncoder 为单个方法指定。
+type ResponseEncoder interface {
+	// EncodeSuccess 包装调用成功时的结果
+	EncodeSuccess(ctx context.Context, data interface{}) interface{}
+	// EncodeError 包装调用失败时的错误
+	EncodeError(ctx context.Context, e *HTTPError) interface{}
+}
+
+// DefaultResponseEncoder 是默认的 {code, message, data} 信封
+var DefaultResponseEncoder ResponseEncoder = jsonResponseEncoder{}
+
+// RawResponseEncoder 直接把结果作为顶层 JSON 输出，不做任何包装；
+// 失败时仍输出 {code, message}（附加信息如校验失败的字段列表放在 data 中），调用方可以通过 HTTP 状态码区分
+var RawResponseEncoder ResponseEncoder = rawResponseEncoder{}
+
+type jsonResponseEncoder struct{}
+
+func (jsonResponseEncoder) EncodeSuccess(ctx context.Context, data interface{}) interface{} {
+	return JsonResponse{Code: 200, Message: "success", Data: data}
+}
+
+func (jsonResponseEncoder) EncodeError(ctx context.Context, e *HTTPError) interface{} {
+	return JsonResponse{Code: e.Code, Message: e.Message, Data: e.Details}
+}
+
+type rawResponseEncoder struct{}
+
+func (rawResponseEncoder) EncodeSuccess(ctx context.Context, data interface{}) interface{} {
+	return data
+}
+
+func (rawResponseEncoder) EncodeError(ctx context.Context, e *HTTPError) interface{} {
+	return JsonResponse{Code: e.Code, Message: e.Message, Data: e.Details}
+}
+
+// EnvelopeFields 定义信封的字段名及成功时的状态码与描述
+type EnvelopeFields struct {
+	Code           string
+	Message        string
+	Data           string
+	SuccessCode    int32
+	SuccessMessage string
+}
+
+// NewEnvelopeEncoder 创建自定义字段名的信封，用于兼容已有接口格式，例如：
+//
+//	http1.NewEnvelopeEncoder(http1.EnvelopeFields{Code: "errno", Message: "errmsg", Data: "result"})
+//
+// 输出 {"errno":0,"errmsg":"","result":{...}}，字段顺序与声明顺序一致。
+func NewEnvelopeEncoder(f EnvelopeFields) ResponseEncoder {
+	typ := reflect.StructOf([]reflect.StructField{
+		{Name: "Code", Type: reflect.TypeOf(int32(0)), Tag: reflect.StructTag(`json:"` + f.Code + `"`)},
+		{Name: "Message", Type: reflect.TypeOf(""), Tag: reflect.StructTag(`json:"` + f.Message + `"`)},
+		{Name: "Data", Type: reflect.TypeOf((*interface{})(nil)).Elem(), Tag: reflect.StructTag(`json:"` + f.Data + `,omitempty"`)},
+	})
+	return &envelopeEncoder{typ: typ, fields: f}
+}
+
+type envelopeEncoder struct {
+	typ    reflect.Type
+	fields EnvelopeFields
+}
+
+func (e *envelopeEncoder) EncodeSuccess(ctx context.Context, data interface{}) interface{} {
+	return e.build(e.fields.SuccessCode, e.fields.SuccessMessage, data)
+}
+
+func (e *envelopeEncoder) EncodeError(ctx context.Context, he *HTTPError) interface{} {
+	return e.build(he.Code, he.Message, he.Details)
+}
+
+func (e *envelopeEncoder) build(code int32, message string, data interface{}) interface{} {
+	v := reflect.New(e.typ).Elem()
+	v.Field(0).SetInt(int64(code))
+	v.Field(1).SetString(message)
+	if data != nil {
+		v.Field(2).Set(reflect.ValueOf(data))
+	}
+	return v.Interface()
+}
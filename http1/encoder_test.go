@@ -0,0 +1,48 @@
+package http1
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeString(t *testing.T, v interface{}) string {
+	var out bytes.Buffer
+	assert.NoError(t, newJSONStreamEncoder(&out, JSONConventions{}).Encode(v))
+	return out.String()
+}
+
+func TestResponseEncoder(t *testing.T) {
+	ctx := context.Background()
+	data := map[string]int{"a": 1}
+	he := &HTTPError{Status: 404, Code: 404, Message: "not found"}
+
+	assert.Equal(t, `{"code":200,"message":"success","data":{"a":1}}`,
+		encodeString(t, DefaultResponseEncoder.EncodeSuccess(ctx, data)))
+	assert.Equal(t, `{"a":1}`, encodeString(t, RawResponseEncoder.EncodeSuccess(ctx, data)))
+
+	legacy := NewEnvelopeEncoder(EnvelopeFields{Code: "errno", Message: "errmsg", Data: "result"})
+	assert.Equal(t, `{"errno":0,"errmsg":"","result":{"a":1}}`,
+		encodeString(t, legacy.EncodeSuccess(ctx, data)))
+	assert.Equal(t, `{"errno":404,"errmsg":"not found"}`,
+		encodeString(t, legacy.EncodeError(ctx, he)))
+}
+
+func TestResponseEncoder_PerMethod(t *testing.T) {
+	h := &HTTP1Handler{bridge: &bridge{options: newOptions([]Option{
+		WithMethodResponseEncoder("Svc", "Raw", RawResponseEncoder),
+	})}}
+	assert.Equal(t, RawResponseEncoder, h.responseEncoder(&request{serviceName: "Svc", methodName: "Raw"}))
+	assert.Equal(t, DefaultResponseEncoder, h.responseEncoder(&request{serviceName: "Svc", methodName: "Other"}))
+	assert.Equal(t, DefaultResponseEncoder, h.responseEncoder(nil))
+
+	h = &HTTP1Handler{bridge: &bridge{options: newOptions([]Option{
+		WithMethodResponseEncoder("Svc", "Raw", RawResponseEncoder),
+		WithMethodResponseEncoder("Svc", "Raw", nil),
+		WithMethodResponseEncoder("Svc", "Nil", nil),
+	})}}
+	assert.Equal(t, DefaultResponseEncoder, h.responseEncoder(&request{serviceName: "Svc", methodName: "Raw"}))
+	assert.Equal(t, DefaultResponseEncoder, h.responseEncoder(&request{serviceName: "Svc", methodName: "Nil"}))
+}
@@ -0,0 +1,31 @@
+package http1
+
+import (
+	"reflect"
+	"sync"
+)
+
+// enumParsers 保存已注册枚举类型的名称解析函数，key 为枚举的 reflect.Type
+var enumParsers sync.Map
+
+// RegisterEnum 注册 Thrift 枚举的名称解析函数（即 kitex_gen 中生成的 XxxFromString），
+// 注册后 HTTP 参数中的枚举既可以传数值，也可以传名称，如：
+//
+//	http1.RegisterEnum(stability.TestEnumFromString)
+func RegisterEnum[T ~int64](fromString func(string) (T, error)) {
+	var zero T
+	enumParsers.Store(reflect.TypeOf(zero), func(s string) (int64, error) {
+		v, err := fromString(s)
+		return int64(v), err
+	})
+}
+
+// parseEnumName 按名称解析枚举值，类型未注册时返回 false
+func parseEnumName(t reflect.Type, s string) (int64, bool, error) {
+	p, ok := enumParsers.Load(t)
+	if !ok {
+		return 0, false, nil
+	}
+	v, err := p.(func(string) (int64, error))(s)
+	return v, true, err
+}
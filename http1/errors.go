@@ -0,0 +1,175 @@
+package http1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/remote"
+)
+
+var (
+	ErrServiceNotFound = errors.New("service not found")
+	ErrMethodNotFound  = errors.New("method not found")
+	ErrInvalidBody     = errors.New("invalid request body")
+)
+
+// HTTPError 描述一次失败调用对应的 HTTP 响应
+type HTTPError struct {
+	// Status 是响应状态行中的 HTTP 状态码
+	Status int
+	// Code 与 Message 写入响应体，业务错误时为业务状态码
+	Code    int32
+	Message string
+	// Header 是需要额外附带的响应头，如 Allow、Retry-After
+	Header map[string]string
+	// Details 是写入响应体的附加信息，如参数校验失败的字段列表
+	Details interface{}
+}
+
+// ErrorMapper 把请求解析、Kitex 调用链及业务 handler 中产生的错误转换为 HTTP 响应，
+// 可以通过 WithErrorMapper 替换默认实现
+type ErrorMapper interface {
+	MapError(ctx context.Context, err error) *HTTPError
+}
+
+// ErrorMapperFunc 是函数形式的 ErrorMapper
+type ErrorMapperFunc func(ctx context.Context, err error) *HTTPError
+
+// MapError 实现 ErrorMapper
+func (f ErrorMapperFunc) MapError(ctx context.Context, err error) *HTTPError {
+	return f(ctx, err)
+}
+
+// DefaultErrorMapper 是默认的错误映射规则：
+//   - 业务状态码本身是 4xx/5xx 时直接作为 HTTP 状态码，其它业务错误返回 400
+//   - 请求行、Header、Body 解析失败、参数绑定失败及 vt.* 校验失败返回 400，请求体过大返回 413，请求行与 Header 过大返回 431
+//   - 路由、服务或方法不存在返回 404，HTTP 方法不被接受返回 405 并带上 Allow 头
+//   - 限流返回 429，熔断与服务发现失败返回 503，超时返回 504，其余返回 500
+var DefaultErrorMapper ErrorMapper = ErrorMapperFunc(defaultMapError)
+
+func defaultMapError(ctx context.Context, err error) *HTTPError {
+	if bizErr, ok := kerrors.FromBizStatusError(err); ok {
+		status := int(bizErr.BizStatusCode())
+		if status < 400 || status > 599 {
+			status = http.StatusBadRequest
+		}
+		return &HTTPError{Status: status, Code: bizErr.BizStatusCode(), Message: bizErr.BizMessage()}
+	}
+
+	var notAllowed *MethodNotAllowedError
+	if errors.As(err, &notAllowed) {
+		e := newHTTPError(http.StatusMethodNotAllowed, err)
+		e.Header = map[string]string{"Allow": strings.Join(notAllowed.Allow, ", ")}
+		return e
+	}
+	var bindErr *BindError
+	if errors.As(err, &bindErr) {
+		return newHTTPError(http.StatusBadRequest, err)
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		e := newHTTPError(http.StatusBadRequest, err)
+		e.Message, e.Details = "validation failed", validationErr.Fields
+		return e
+	}
+	var transErr *remote.TransError
+	if errors.As(err, &transErr) {
+		switch transErr.TypeID() {
+		case remote.UnknownMethod, remote.UnknownService, remote.WrongMethodName:
+			return newHTTPError(http.StatusNotFound, err)
+		case remote.ProtocolError, remote.InvalidProtocol:
+			return newHTTPError(http.StatusBadRequest, err)
+		}
+	}
+
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		return newHTTPError(http.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, ErrHeaderTooLarge):
+		return newHTTPError(http.StatusRequestHeaderFieldsTooLarge, err)
+	case errors.Is(err, ErrUnsupportedTransferCoding):
+		return newHTTPError(http.StatusNotImplemented, err)
+	case errors.Is(err, ErrInvalidPathFormat), errors.Is(err, ErrServiceNotFound), errors.Is(err, ErrMethodNotFound):
+		return newHTTPError(http.StatusNotFound, err)
+	case isRequestError(err), errors.Is(err, ErrInvalidBody), errors.Is(err, ErrInvalidPathEscape):
+		return newHTTPError(http.StatusBadRequest, err)
+	case errors.Is(err, kerrors.ErrOverlimit):
+		return newHTTPError(http.StatusTooManyRequests, err)
+	case errors.Is(err, kerrors.ErrACL):
+		return newHTTPError(http.StatusForbidden, err)
+	case errors.Is(err, kerrors.ErrCircuitBreak), errors.Is(err, kerrors.ErrServiceDiscovery),
+		errors.Is(err, kerrors.ErrNoMoreInstance), errors.Is(err, kerrors.ErrGetConnection):
+		return newHTTPError(http.StatusServiceUnavailable, err)
+	case errors.Is(err, kerrors.ErrRPCTimeout), errors.Is(err, kerrors.ErrTimeoutByBusiness),
+		errors.Is(err, context.DeadlineExceeded):
+		return newHTTPError(http.StatusGatewayTimeout, err)
+	}
+	return newHTTPError(http.StatusInternalServerError, err)
+}
+
+// newHTTPError 构造系统错误：4xx 返回具体原因，5xx 只返回状态描述，避免泄露内部实现
+func newHTTPError(status int, err error) *HTTPError {
+	msg := err.Error()
+	if status >= http.StatusInternalServerError {
+		msg = strings.ToLower(http.StatusText(status))
+	}
+	return &HTTPError{Status: status, Code: int32(status), Message: msg}
+}
+
+// isRequestError 判断是否为 HTTP 报文本身格式错误，这类错误发生后连接上的报文边界已不可信
+func isRequestError(err error) bool {
+	for _, target := range []error{
+		ErrInvalidRequestLine, ErrInvalidContentLen, ErrUnsupportedProto,
+		ErrInvalidChunk, ErrBodyTooLarge, ErrUnsupportedTransferCoding, ErrHeaderTooLarge,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProblemDetails 是 RFC 7807 定义的 application/problem+json 响应体
+type ProblemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Code 为扩展成员，保留业务状态码
+	Code int32 `json:"code"`
+	// Errors 为扩展成员，即 HTTPError.Details
+	Errors interface{} `json:"errors,omitempty"`
+}
+
+// writeError 按 HTTPError 写出错误响应：Body 由 ResponseEncoder 决定，开启 ProblemDetails 时使用 RFC 7807 格式
+func (h *HTTP1Handler) writeError(ctx context.Context, cs *connState, e *HTTPError) error {
+	rw := newResponseWriter(cs.writer, cs.req, h.options.ChunkedThreshold)
+	rw.SetStatus(e.Status)
+	for k, v := range e.Header {
+		rw.AddHeader(k, v)
+	}
+	contentType, body := h.errorBody(ctx, cs.req, e)
+	rw.AddHeader("Content-Type", contentType)
+	if err := newJSONStreamEncoder(rw, h.options.JSON).Encode(body); err != nil {
+		return err
+	}
+	return rw.Finish()
+}
+
+// errorBody 返回错误响应的 Content-Type 与 Body
+func (h *HTTP1Handler) errorBody(ctx context.Context, req *request, e *HTTPError) (string, interface{}) {
+	if h.options.ProblemDetails {
+		return "application/problem+json", ProblemDetails{
+			Type:   "about:blank",
+			Title:  http.StatusText(e.Status),
+			Status: e.Status,
+			Detail: e.Message,
+			Code:   e.Code,
+			Errors: e.Details,
+		}
+	}
+	return "application/json", h.responseEncoder(req).EncodeError(ctx, e)
+}
@@ -0,0 +1,45 @@
+package http1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/kerrors"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultErrorMapper(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{kerrors.NewBizStatusError(404, "user not found"), http.StatusNotFound},
+		{kerrors.NewBizStatusError(10001, "balance not enough"), http.StatusBadRequest},
+		{fmt.Errorf("failed to read http request: %w", ErrInvalidRequestLine), http.StatusBadRequest},
+		{fmt.Errorf("%w: unexpected end of JSON input", ErrInvalidBody), http.StatusBadRequest},
+		{&BindError{Field: "On", Source: "query", Key: "on", Err: errors.New("invalid syntax")}, http.StatusBadRequest},
+		{ErrInvalidPathFormat, http.StatusNotFound},
+		{fmt.Errorf("%w: getUser", ErrMethodNotFound), http.StatusNotFound},
+		{remote.NewTransErrorWithMsg(remote.UnknownMethod, "unknown method"), http.StatusNotFound},
+		{&MethodNotAllowedError{Method: "PUT", Allow: []string{"GET"}}, http.StatusMethodNotAllowed},
+		{ErrBodyTooLarge, http.StatusRequestEntityTooLarge},
+		{kerrors.ErrQPSOverLimit, http.StatusTooManyRequests},
+		{kerrors.ErrServiceCircuitBreak, http.StatusServiceUnavailable},
+		{kerrors.ErrRPCTimeout.WithCause(errors.New("deadline")), http.StatusGatewayTimeout},
+		{kerrors.ErrBiz.WithCause(errors.New("db down")), http.StatusInternalServerError},
+	}
+	for _, c := range cases {
+		e := DefaultErrorMapper.MapError(context.Background(), c.err)
+		assert.Equal(t, c.status, e.Status, c.err.Error())
+	}
+
+	e := DefaultErrorMapper.MapError(context.Background(), &MethodNotAllowedError{Method: "PUT", Allow: []string{"GET", "POST"}})
+	assert.Equal(t, "GET, POST", e.Header["Allow"])
+
+	e = DefaultErrorMapper.MapError(context.Background(), kerrors.ErrBiz.WithCause(errors.New("db password wrong")))
+	assert.Equal(t, "internal server error", e.Message)
+}
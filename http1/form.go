@@ -0,0 +1,112 @@
+package http1
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+)
+
+// 单个 multipart 请求最多包含的 part 数量，避免大量空 part 消耗资源
+const maxFormParts = 1000
+
+// isFormBody 判断 Body 是否为表单（application/x-www-form-urlencoded 或 multipart/form-data），表单不按 JSON 解码
+func isFormBody(contentType string) bool {
+	if isFormURLEncoded(contentType) {
+		return true
+	}
+	_, ok := multipartBoundary(contentType)
+	return ok
+}
+
+// multipartBoundary 返回 multipart/form-data 的分隔符，其他类型返回 false
+func multipartBoundary(contentType string) (string, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.EqualFold(mediaType, "multipart/form-data") {
+		return "", false
+	}
+	boundary := params["boundary"]
+	return boundary, boundary != ""
+}
+
+// parseForm 按 Content-Type 解析已读入内存的表单 Body，非表单 Body 返回 nil。
+// HTTP/1.1 与 h2c 的 multipart Body 在读取请求时已由 readMultipart 从连接上流式解析，不会走到这里
+func parseForm(contentType string, body []byte, maxPartSize int) (url.Values, error) {
+	if isFormURLEncoded(contentType) {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed form: %v", ErrInvalidBody, err)
+		}
+		return form, nil
+	}
+	boundary, ok := multipartBoundary(contentType)
+	if !ok {
+		return nil, nil
+	}
+	return readMultipart(bytes.NewReader(body), boundary, maxPartSize)
+}
+
+// readMultipart 从 r 中逐个读取 multipart/form-data 的 part，不预先缓冲整个 Body。
+// 文件 part 与普通 part 一样以 part 名称登记，值为文件的原始内容，可以绑定到 binary 或 string 字段；
+// 每个 part 读取时即受 maxPartSize 限制，超过时返回 ErrBodyTooLarge，r 返回的 ErrBodyTooLarge 原样返回
+func readMultipart(r io.Reader, boundary string, maxPartSize int) (url.Values, error) {
+	form := make(url.Values)
+	mr := multipart.NewReader(r, boundary)
+	for n := 0; ; n++ {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return form, nil
+		}
+		if err != nil {
+			return nil, multipartError(err)
+		}
+		if n >= maxFormParts {
+			return nil, fmt.Errorf("%w: more than %d multipart parts", ErrBodyTooLarge, maxFormParts)
+		}
+		name := part.FormName()
+		if name == "" {
+			part.Close()
+			continue
+		}
+		var value strings.Builder
+		size, err := io.Copy(&value, io.LimitReader(part, int64(maxPartSize)+1))
+		part.Close()
+		if err != nil {
+			return nil, multipartError(err)
+		}
+		if size > int64(maxPartSize) {
+			return nil, fmt.Errorf("%w: multipart part %q exceeds %d bytes", ErrBodyTooLarge, name, maxPartSize)
+		}
+		form[name] = append(form[name], value.String())
+	}
+}
+
+func multipartError(err error) error {
+	if errors.Is(err, ErrBodyTooLarge) {
+		return err
+	}
+	return fmt.Errorf("%w: malformed multipart: %v", ErrInvalidBody, err)
+}
+
+// maxBytesReader 在读取的总长度超过 left 时返回 ErrBodyTooLarge
+type maxBytesReader struct {
+	r    io.Reader
+	left int
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if len(p) > m.left+1 {
+		p = p[:m.left+1]
+	}
+	n, err := m.r.Read(p)
+	if n > m.left {
+		n, m.left = m.left, 0
+		return n, ErrBodyTooLarge
+	}
+	m.left -= n
+	return n, err
+}
@@ -0,0 +1,199 @@
+package http1
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/netpoll"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+)
+
+// multipartBody 构造包含普通字段与一个文件的 multipart/form-data Body
+func multipartBody(t *testing.T, fields map[string]string, file, content string) (string, []byte) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		require.NoError(t, w.WriteField(k, v))
+	}
+	if file != "" {
+		fw, err := w.CreateFormFile(file, "upload.bin")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return w.FormDataContentType(), buf.Bytes()
+}
+
+func TestParseForm(t *testing.T) {
+	ct, body := multipartBody(t, map[string]string{"Name": "bob", "int32": "7"}, "bin", "\x00\x01raw")
+	form, err := parseForm(ct, body, 16)
+	require.NoError(t, err)
+	assert.Equal(t, "bob", form.Get("Name"))
+	assert.Equal(t, "\x00\x01raw", form.Get("bin"))
+
+	src := newBindingSource(&request{})
+	src.form = form
+	args := stability.NewSTServiceTestSTReqArgs()
+	require.NoError(t, bindArgs(args, src))
+	assert.Equal(t, "bob", args.Req.GetName())
+	assert.Equal(t, int32(7), args.Req.GetInt32())
+	assert.Equal(t, []byte("\x00\x01raw"), args.Req.GetBin())
+
+	_, err = parseForm(ct, body, 4)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+	_, err = parseForm(ct, body[:len(body)-10], 16)
+	assert.ErrorIs(t, err, ErrInvalidBody)
+
+	form, err = parseForm("application/json", body, 16)
+	assert.NoError(t, err)
+	assert.Nil(t, form)
+}
+
+func TestFormBinding_BinaryList(t *testing.T) {
+	type upload struct {
+		Files [][]byte `form:"files"`
+		Tags  []string `form:"tags"`
+	}
+	src := newBindingSource(&request{})
+	src.form = map[string][]string{"files": {"a, b\n"}, "tags": {"x, y"}}
+	src.query = map[string][]string{"tags": {"q"}}
+	var v upload
+	require.NoError(t, bindArgs(&v, src))
+	assert.Equal(t, [][]byte{[]byte("a, b\n")}, v.Files)
+	assert.Equal(t, []string{"x, y"}, v.Tags)
+
+	type query struct {
+		Tags []string `query:"tags"`
+	}
+	src.query = map[string][]string{"tags": {"x, y"}}
+	var q query
+	require.NoError(t, bindArgs(&q, src))
+	assert.Equal(t, []string{"x", "y"}, q.Tags)
+}
+
+func TestFormBinding_HTTP(t *testing.T) {
+	addr := startServer(t, WithMaxFormPartSize(16))
+	post := func(ct string, body []byte) (int, map[string]interface{}) {
+		status, out := postBody(t, "http://"+addr+"/api/STService/testSTReq", ct, string(body))
+		data, _ := out["data"].(map[string]interface{})
+		return status, data
+	}
+
+	status, data := post("application/x-www-form-urlencoded", []byte("Name=bob&int32=1"))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "bob", data["name"])
+
+	ct, body := multipartBody(t, map[string]string{"Name": "alice"}, "bin", "payload")
+	status, data = post(ct, body)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "alice", data["name"])
+
+	ct, body = multipartBody(t, map[string]string{"Name": "carol"}, "bin", strings.Repeat("x", 17))
+	status, _ = post(ct, body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+}
+
+func TestFormBinding_HTTP2(t *testing.T) {
+	addr := startH2Server(t, WithMaxFormPartSize(16))
+	post := func(ct string, body []byte) int {
+		resp, err := h2cClient().Post("http://"+addr+"/api/STService/testSTReq", ct, bytes.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		raw, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(raw), `"name":"alice"`)
+		return resp.StatusCode
+	}
+	ct, body := multipartBody(t, map[string]string{"Name": "alice"}, "bin", "payload")
+	assert.Equal(t, http.StatusOK, post(ct, body))
+}
+
+func TestMaxFormPartSize_Option(t *testing.T) {
+	_, err := NewSvrTransHandlerFactory(WithMaxBodySize(1024), WithMaxFormPartSize(2048)).NewTransHandler(nil)
+	assert.Error(t, err)
+
+	b, err := newBridge(newOptions([]Option{WithMaxBodySize(1024)}), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1024, b.options.MaxFormPartSize)
+	b.stop()
+
+	b, err = newBridge(newOptions(nil), nil)
+	require.NoError(t, err)
+	assert.Equal(t, defaultMaxFormPartSize, b.options.MaxFormPartSize)
+	b.stop()
+}
+
+func TestReadRequest_MultipartStreaming(t *testing.T) {
+	ct, body := multipartBody(t, map[string]string{"Name": "bob"}, "bin", "payload")
+	head := func(extra string) string {
+		return "POST /api/STService/testSTReq HTTP/1.1\r\nContent-Type: " + ct + "\r\n" + extra + "\r\n"
+	}
+	next := "GET /next HTTP/1.1\r\n\r\n"
+
+	t.Run("content-length", func(t *testing.T) {
+		input := head("Content-Length: "+strconv.Itoa(len(body))+"\r\n") + string(body) + next
+		r := netpoll.NewReader(strings.NewReader(input))
+		req, err := readRequest(r, formLimits(16))
+		require.NoError(t, err)
+		assert.Empty(t, req.body)
+		require.NoError(t, req.formErr)
+		assert.Equal(t, "bob", req.form.Get("Name"))
+		assert.Equal(t, "payload", req.form.Get("bin"))
+		assert.True(t, req.keepAlive)
+
+		// Body 被完整消费，流水线中的下一个请求不受影响
+		second, err := readRequest(r, formLimits(16))
+		require.NoError(t, err)
+		assert.Equal(t, "/next", second.path)
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		half := len(body) / 2
+		chunks := strconv.FormatInt(int64(half), 16) + "\r\n" + string(body[:half]) + "\r\n" +
+			strconv.FormatInt(int64(len(body)-half), 16) + "\r\n" + string(body[half:]) + "\r\n0\r\nX-Sum: 1\r\n\r\n"
+		r := netpoll.NewReader(strings.NewReader(head("Transfer-Encoding: chunked\r\n") + chunks + next))
+		req, err := readRequest(r, formLimits(16))
+		require.NoError(t, err)
+		require.NoError(t, req.formErr)
+		assert.Equal(t, "payload", req.form.Get("bin"))
+		assert.Equal(t, "1", req.headers["X-Sum"])
+		second, err := readRequest(r, formLimits(16))
+		require.NoError(t, err)
+		assert.Equal(t, "/next", second.path)
+	})
+
+	t.Run("malformed keeps connection", func(t *testing.T) {
+		bad := body[:len(body)-10]
+		input := head("Content-Length: "+strconv.Itoa(len(bad))+"\r\n") + string(bad) + next
+		r := netpoll.NewReader(strings.NewReader(input))
+		req, err := readRequest(r, formLimits(16))
+		require.NoError(t, err)
+		assert.ErrorIs(t, req.formErr, ErrInvalidBody)
+		assert.True(t, req.keepAlive)
+		second, err := readRequest(r, formLimits(16))
+		require.NoError(t, err)
+		assert.Equal(t, "/next", second.path)
+	})
+
+	t.Run("part too large closes connection", func(t *testing.T) {
+		input := head("Content-Length: "+strconv.Itoa(len(body))+"\r\n") + string(body)
+		req, err := readRequest(netpoll.NewReader(strings.NewReader(input)), formLimits(4))
+		require.NoError(t, err)
+		assert.ErrorIs(t, req.formErr, ErrBodyTooLarge)
+		assert.False(t, req.keepAlive)
+	})
+}
+
+func formLimits(partSize int) readLimits {
+	return readLimits{headerBytes: defaultMaxHeaderBytes, body: defaultMaxBodySize, formPart: partSize}
+}
@@ -0,0 +1,174 @@
+package http1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cloudwego/kitex/client"
+	"github.com/cloudwego/kitex/client/genericclient"
+	"github.com/cloudwego/kitex/pkg/generic"
+	"github.com/cloudwego/kitex/pkg/klog"
+)
+
+// 泛化模式：按 .thrift IDL 把 JSON Body 直接转换为 Thrift binary（dynamicgo）转发给下游服务，
+// KitBridge 不需要链接下游服务的 kitex_gen 代码
+
+// GenericService 描述一个按 IDL 泛化转发的下游 Thrift 服务
+type GenericService struct {
+	// IDL 是主 .thrift 文件路径，IncludeDirs 为 include 语句的搜索目录
+	IDL         string
+	IncludeDirs []string
+	// Service 是 IDL 中的服务名，为空时使用主文件中最后一个服务
+	Service string
+	// Addresses 是下游服务的 "host:port" 列表
+	Addresses []string
+}
+
+// errGenericCall 由 Read 返回，表示请求的服务由泛化客户端转发，不经过本地 Kitex endpoint
+var errGenericCall = errors.New("generic call")
+
+// genericTarget 是一个下游服务的泛化客户端及其 IDL 描述
+type genericTarget struct {
+	svc      GenericService
+	client   genericclient.Client
+	generic  generic.Generic
+	provider *generic.ThriftContentWithAbsIncludePathProvider
+
+	mu      sync.Mutex
+	version idlVersion
+}
+
+// reload 在 IDL 文件变化时替换描述，返回是否已替换；解析失败时 provider 保留之前的描述
+func (t *genericTarget) reload() (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.version.reload(func() (*idlSnapshot, error) {
+		snap, _, err := loadIDL(t.svc.IDL, t.svc.IncludeDirs)
+		if err != nil {
+			return nil, err
+		}
+		return snap, t.provider.UpdateIDL(snap.main, snap.contents)
+	})
+}
+
+// genericGroup 是一次 WithGenericServices 配置的服务，客户端在第一次创建处理器时建立，
+// HTTP/1 与 HTTP/2 工厂共用同一组 Option 时也共用同一组客户端
+type genericGroup struct {
+	services []GenericService
+	once     sync.Once
+	clients  map[string]*genericTarget
+	err      error
+}
+
+func (g *genericGroup) init() (map[string]*genericTarget, error) {
+	g.once.Do(func() {
+		g.clients = make(map[string]*genericTarget, len(g.services))
+		for _, svc := range g.services {
+			name, target, err := newGenericTarget(svc)
+			if err != nil {
+				g.err = fmt.Errorf("load generic service from %s: %w", svc.IDL, err)
+				return
+			}
+			g.clients[name] = target
+		}
+	})
+	return g.clients, g.err
+}
+
+func newGenericTarget(svc GenericService) (string, *genericTarget, error) {
+	snap, ast, err := loadIDL(svc.IDL, svc.IncludeDirs)
+	if err != nil {
+		return "", nil, err
+	}
+	name := svc.Service
+	if name == "" {
+		if len(ast.Services) == 0 {
+			return "", nil, errors.New("no service defined")
+		}
+		name = ast.Services[len(ast.Services)-1].Name
+	}
+	if len(svc.Addresses) == 0 {
+		return "", nil, fmt.Errorf("no address for service %s", name)
+	}
+	// 以文件内容创建 provider，热加载时通过 UpdateIDL 替换
+	p, err := generic.NewThriftContentWithAbsIncludePathProviderWithDynamicGo(snap.main, snap.contents, generic.WithIDLServiceName(name))
+	if err != nil {
+		return "", nil, err
+	}
+	g, err := generic.JSONThriftGeneric(p)
+	if err != nil {
+		return "", nil, err
+	}
+	cli, err := genericclient.NewClient(name, g, client.WithHostPorts(svc.Addresses...))
+	if err != nil {
+		return "", nil, err
+	}
+	return name, &genericTarget{svc: svc, client: cli, generic: g, provider: p, version: idlVersion{snap: snap}}, nil
+}
+
+// newGenericTargets 合并各组配置的下游服务，同名服务以后配置的为准
+func newGenericTargets(groups []*genericGroup) (map[string]*genericTarget, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	clients := make(map[string]*genericTarget)
+	for _, g := range groups {
+		m, err := g.init()
+		if err != nil {
+			return nil, err
+		}
+		for name, target := range m {
+			clients[name] = target
+		}
+	}
+	return clients, nil
+}
+
+// isGeneric 判断请求的服务是否由泛化客户端转发，HTTP/1、HTTP/2 与 WebSocket 在确定服务方法后都先检查这里
+func (h *HTTP1Handler) isGeneric(req *request) bool {
+	_, ok := h.generic[req.serviceName]
+	return ok
+}
+
+// callGeneric 把 Body 作为方法唯一参数的 JSON 交给泛化客户端，返回下游的 JSON 结果。
+// 没有 Body 时以空 struct 调用；Header/Query 等参数绑定依赖生成代码的 tag，泛化模式下不生效
+func (h *HTTP1Handler) callGeneric(ctx context.Context, req *request) (interface{}, error) {
+	target := h.generic[req.serviceName]
+	if _, err := target.generic.GetMethod(nil, req.methodName); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMethodNotFound, req.methodName)
+	}
+	body := "{}"
+	if len(req.body) > 0 {
+		if !json.Valid(req.body) {
+			return nil, fmt.Errorf("%w: malformed json", ErrInvalidBody)
+		}
+		body = string(req.body)
+	}
+	klog.CtxDebugf(ctx, "HTTP generic call: service=%s, method=%s", req.serviceName, req.methodName)
+	resp, err := target.client.GenericCall(ctx, req.methodName, body)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	if s, ok := resp.(string); ok && s != "" {
+		data = json.RawMessage(s)
+	}
+	return data, nil
+}
+
+// serveGeneric 在 HTTP/1 连接上执行泛化调用并写回响应
+func (h *HTTP1Handler) serveGeneric(ctx context.Context, cs *connState) error {
+	data, err := h.callGeneric(ctx, cs.req)
+	if err != nil {
+		return h.writeError(ctx, cs, h.options.ErrorMapper.MapError(ctx, err))
+	}
+	rw := newResponseWriter(cs.writer, cs.req, h.options.ChunkedThreshold)
+	rw.AddHeader("Content-Type", "application/json")
+	if err := newJSONStreamEncoder(rw, h.options.JSON).Encode(h.responseEncoder(cs.req).EncodeSuccess(ctx, data)); err != nil {
+		return err
+	}
+	return rw.Finish()
+}
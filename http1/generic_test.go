@@ -0,0 +1,98 @@
+package http1
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability/stservice"
+)
+
+type remoteService struct{}
+
+func (*remoteService) TestSTReq(ctx context.Context, req *stability.STRequest) (*stability.STResponse, error) {
+	return &stability.STResponse{Name: stringPtr("remote:" + req.GetName()), Mp: req.StringMap}, nil
+}
+
+func stringPtr(s string) *string { return &s }
+
+// startRemote 启动作为泛化转发下游的普通 Kitex Thrift 服务
+func startRemote(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	downstream := stservice.NewServer(&remoteService{}, server.WithListener(ln))
+	go downstream.Run()
+	t.Cleanup(func() { downstream.Stop() })
+	time.Sleep(200 * time.Millisecond)
+	return ln.Addr().String()
+}
+
+func TestGenericService(t *testing.T) {
+	addr := startServer(t,
+		WithRoutes(Route{HTTPMethod: http.MethodPost, Path: "/v2/st", ServiceName: "STService", MethodName: "testSTReq"}),
+		WithGenericServices(GenericService{IDL: "../idl/stability.thrift", Addresses: []string{startRemote(t)}}))
+
+	post := func(t *testing.T, path, body string) (int, map[string]interface{}) {
+		return postBody(t, "http://"+addr+path, "application/json", body)
+	}
+
+	t.Run("forward", func(t *testing.T) {
+		status, out := post(t, "/api/STService/testSTReq", `{"Name":"bob","stringMap":{"k":"v"}}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, map[string]interface{}{"name": "remote:bob", "mp": map[string]interface{}{"k": "v"}}, out["data"])
+	})
+
+	t.Run("route", func(t *testing.T) {
+		status, out := post(t, "/v2/st", `{"Name":"carol"}`)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "remote:carol", out["data"].(map[string]interface{})["name"])
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		status, _ := post(t, "/api/STService/nope", `{}`)
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		status, _ := post(t, "/api/STService/testSTReq", `{`)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+}
+
+func TestGenericService_HTTP2AndWebSocket(t *testing.T) {
+	opts := []Option{
+		WithWebSocket("/ws"),
+		WithGenericServices(GenericService{IDL: "../idl/stability.thrift", Addresses: []string{startRemote(t)}}),
+	}
+
+	addr := startH2Server(t, opts...)
+	resp, err := h2cClient().Post("http://"+addr+"/api/STService/testSTReq", "application/json", strings.NewReader(`{"Name":"bob"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, string(raw))
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+	assert.Contains(t, string(raw), `"name":"remote:bob"`)
+
+	c := dialWebSocket(t, startServer(t, opts...), "/ws")
+	c.send(t, true, wsOpText, []byte(`{"id":1,"service":"STService","method":"testSTReq","args":{"Name":"ws"}}`))
+	out := c.recvResponse(t)
+	assert.Equal(t, float64(http.StatusOK), out["status"])
+	assert.Equal(t, "remote:ws", out["body"].(map[string]interface{})["data"].(map[string]interface{})["name"])
+}
+
+func TestGenericService_InvalidIDL(t *testing.T) {
+	_, err := NewSvrTransHandlerFactory(WithGenericServices(GenericService{IDL: "missing.thrift", Addresses: []string{"127.0.0.1:1"}})).
+		NewTransHandler(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,393 @@
+package http1
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/endpoint"
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/codes"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+	"golang.org/x/net/http2"
+
+	"github.com/BeroKiTeer/KitBridge/protocol"
+)
+
+// HTTP2SvrTransHandlerFactory 创建处理 h2c（HTTP/2 prior knowledge）连接的 handler。
+// 每个 HTTP/2 stream 是一次调用：普通请求按与 HTTP/1 相同的 JSON → Thrift 映射处理，
+// Content-Type 为 application/grpc 的请求按 gRPC 帧格式处理。
+type HTTP2SvrTransHandlerFactory struct {
+	http1 *HTTP1SvrTransHandlerFactory
+}
+
+// NewHTTP2SvrTransHandlerFactory 创建独立的 HTTP/2 处理器工厂；与 HTTP/1 同时使用时应通过
+// HTTP1SvrTransHandlerFactory.HTTP2 创建，两者共用同一份路由表、IDL 热加载与泛化客户端
+func NewHTTP2SvrTransHandlerFactory(opts ...Option) *HTTP2SvrTransHandlerFactory {
+	return NewSvrTransHandlerFactory(opts...).HTTP2()
+}
+
+// HTTP2 返回与 f 共用配置与状态的 HTTP/2 处理器工厂
+func (f *HTTP1SvrTransHandlerFactory) HTTP2() *HTTP2SvrTransHandlerFactory {
+	return &HTTP2SvrTransHandlerFactory{http1: f}
+}
+
+func (f *HTTP2SvrTransHandlerFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	core, err := f.http1.NewTransHandler(opt)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTP2Handler{core: core.(*HTTP1Handler), server: &http2.Server{}}, nil
+}
+
+// HTTP2Handler 复用 HTTP1Handler 的路由、参数绑定与响应编码，由 x/net/http2 负责帧的收发
+type HTTP2Handler struct {
+	core   *HTTP1Handler
+	server *http2.Server
+}
+
+func (h *HTTP2Handler) ProtocolMatch(ctx context.Context, conn net.Conn) error {
+	if s, ok := protocol.LookupSniffer(protocol.ProtocolHttp2); ok && s.MatchConn(conn) {
+		return nil
+	}
+	return errors.New("error protocol not match")
+}
+
+// OnRead 在整个连接生命周期内运行 HTTP/2 服务端，连接关闭后返回
+func (h *HTTP2Handler) OnRead(ctx context.Context, conn net.Conn) error {
+	h.server.ServeConn(conn, &http2.ServeConnOpts{
+		Context: ctx,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case isGRPCRequest(r):
+				h.serveGRPC(conn, w, r)
+			case isThriftContentType(r.Header.Get("Content-Type")):
+				h.serveThrift(conn, w, r)
+			default:
+				h.serveJSON(conn, w, r)
+			}
+		}),
+	})
+	return nil
+}
+
+// serveJSON 处理一个 HTTP/2 stream 上的 JSON 请求，流程与 HTTP/1 的 Read → OnMessage → Write 一致
+func (h *HTTP2Handler) serveJSON(conn net.Conn, w http.ResponseWriter, r *http.Request) {
+	ri := h.core.newRPCInfo(conn)
+	ctx := rpcinfo.NewCtxWithRPCInfo(r.Context(), ri)
+
+	req, err := newRequestFromHTTP(r, h.core.options.MaxBodySize, h.core.options.MaxFormPartSize)
+	if err == nil {
+		err = h.core.resolveRoute(req)
+	}
+	var data interface{}
+	if err == nil {
+		data, err = h.core.invoke(ctx, ri, req)
+	}
+	if err != nil {
+		h.writeJSONError(ctx, w, req, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	resp := h.core.responseEncoder(req).EncodeSuccess(ctx, data)
+	if err := newJSONStreamEncoder(w, h.core.options.JSON).Encode(resp); err != nil {
+		// 响应头已经发出，只能中断 stream 让客户端感知到异常
+		klog.CtxErrorf(ctx, "HTTP2 encode response failed: %v", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// serveThrift 处理 HTTP/2 stream 上 Apache Thrift HTTP 客户端的请求，与 HTTP/1 的 serveThriftHTTP 一致
+func (h *HTTP2Handler) serveThrift(conn net.Conn, w http.ResponseWriter, r *http.Request) {
+	req, err := newRequestFromHTTP(r, h.core.options.MaxBodySize, h.core.options.MaxFormPartSize)
+	var body []byte
+	if err == nil {
+		body, err = h.core.callThriftHTTP(r.Context(), conn, req)
+	}
+	if err != nil {
+		h.writeJSONError(r.Context(), w, req, err)
+		return
+	}
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	_, _ = w.Write(body)
+}
+
+func (h *HTTP2Handler) writeJSONError(ctx context.Context, w http.ResponseWriter, req *request, err error) {
+	e := h.core.options.ErrorMapper.MapError(ctx, err)
+	contentType, body := h.core.errorBody(ctx, req, e)
+	for k, v := range e.Header {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(e.Status)
+	_ = newJSONStreamEncoder(w, h.core.options.JSON).Encode(body)
+}
+
+// newRequestFromHTTP 把 net/http 的请求转换为内部的 request，Body 大小受 maxBodySize 限制；
+// multipart/form-data 的 Body 与 HTTP/1 一样边读边按 part 解析，单个 part 受 maxFormPartSize 限制
+func newRequestFromHTTP(r *http.Request, maxBodySize, maxFormPartSize int) (*request, error) {
+	headers := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		headers[k] = strings.Join(v, ", ")
+	}
+	req := &request{
+		method:    r.Method,
+		path:      r.URL.Path,
+		rawQuery:  r.URL.RawQuery,
+		proto:     r.Proto,
+		headers:   headers,
+		keepAlive: true,
+	}
+	if boundary, ok := multipartBoundary(r.Header.Get("Content-Type")); ok {
+		req.form, req.formErr = readMultipart(&maxBytesReader{r: r.Body, left: maxBodySize}, boundary, maxFormPartSize)
+		return req, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(maxBodySize)+1))
+	if err != nil {
+		return req, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+	}
+	if len(body) > maxBodySize {
+		return req, ErrBodyTooLarge
+	}
+	req.body = body
+	return req, nil
+}
+
+// gRPC 消息前缀：1 字节压缩标记 + 4 字节长度
+const grpcHeaderSize = 5
+
+func isGRPCRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// serveGRPC 处理 unary gRPC 调用：路径为 /{package.Service}/{Method}。
+// Kitex protobuf 服务的 Args/Result 通过生成的 Marshal/Unmarshal 编解码；
+// Content-Type 为 application/grpc+thrift 时按 Thrift binary 编解码
+func (h *HTTP2Handler) serveGRPC(conn net.Conn, w http.ResponseWriter, r *http.Request) {
+	ri := h.core.newRPCInfo(conn)
+	ctx := rpcinfo.NewCtxWithRPCInfo(r.Context(), ri)
+	contentType := r.Header.Get("Content-Type")
+	w.Header().Set("Content-Type", contentType)
+
+	serviceName, methodName, ok := splitGRPCPath(r.URL.Path)
+	if !ok {
+		writeGRPCStatus(w, codes.Unimplemented, "malformed method name: "+r.URL.Path)
+		return
+	}
+	svcInfo := h.core.opt.SvcSearcher.SearchService(serviceName, methodName, false)
+	var mtInfo serviceinfo.MethodInfo
+	if svcInfo != nil {
+		mtInfo = svcInfo.MethodInfo(methodName)
+	}
+	if mtInfo == nil {
+		writeGRPCStatus(w, codes.Unimplemented, "unknown method "+r.URL.Path)
+		return
+	}
+	setInvocation(ri, svcInfo, methodName)
+
+	payload, err := readGRPCMessage(r.Body, h.core.options.MaxBodySize)
+	if err != nil {
+		writeGRPCStatus(w, grpcCode(h.core.options.ErrorMapper.MapError(ctx, err).Status), err.Error())
+		return
+	}
+	thriftCodec := strings.HasPrefix(contentType, "application/grpc+thrift")
+	args := mtInfo.NewArgs()
+	if err := unmarshalGRPCArgs(args, payload, thriftCodec); err != nil {
+		writeGRPCStatus(w, codes.InvalidArgument, err.Error())
+		return
+	}
+
+	result := mtInfo.NewResult()
+	if err := h.core.handlerFunc(ctx, args, result); err != nil {
+		writeGRPCStatus(w, grpcCode(h.core.options.ErrorMapper.MapError(ctx, err).Status), err.Error())
+		return
+	}
+	if bizErr := ri.Invocation().BizStatusErr(); bizErr != nil {
+		writeGRPCStatus(w, grpcCode(h.core.options.ErrorMapper.MapError(ctx, bizErr).Status), bizErr.BizMessage())
+		return
+	}
+	out, err := marshalGRPCResult(result, thriftCodec)
+	if err != nil {
+		writeGRPCStatus(w, codes.Internal, err.Error())
+		return
+	}
+	frame := make([]byte, grpcHeaderSize, grpcHeaderSize+len(out))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(out)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(frame, out...))
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", "0")
+}
+
+// splitGRPCPath 解析 /{package.Service}/{Method}，返回不带 package 的服务名
+func splitGRPCPath(path string) (serviceName, methodName string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	serviceName = parts[0]
+	if i := strings.LastIndexByte(serviceName, '.'); i >= 0 {
+		serviceName = serviceName[i+1:]
+	}
+	return serviceName, parts[1], true
+}
+
+// readGRPCMessage 读取 unary 调用的唯一一条消息，不支持压缩
+func readGRPCMessage(body io.Reader, maxSize int) ([]byte, error) {
+	var hdr [grpcHeaderSize]byte
+	if _, err := io.ReadFull(body, hdr[:]); err != nil {
+		return nil, fmt.Errorf("%w: read grpc message header: %v", ErrInvalidBody, err)
+	}
+	if hdr[0] != 0 {
+		return nil, fmt.Errorf("%w: grpc compression is not supported", ErrUnsupportedTransferCoding)
+	}
+	size := binary.BigEndian.Uint32(hdr[1:])
+	if int64(size) > int64(maxSize) {
+		return nil, ErrBodyTooLarge
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(body, payload); err != nil {
+		return nil, fmt.Errorf("%w: read grpc message: %v", ErrInvalidBody, err)
+	}
+	return payload, nil
+}
+
+// Kitex protobuf 生成的 Args/Result 提供的编解码方法
+type protoArgs interface {
+	Unmarshal(in []byte) error
+}
+
+type protoResult interface {
+	Marshal(out []byte) ([]byte, error)
+}
+
+func unmarshalGRPCArgs(args interface{}, payload []byte, thriftCodec bool) error {
+	if thriftCodec {
+		s, ok := args.(thrift.TStruct)
+		if !ok {
+			return fmt.Errorf("args %T do not support thrift encoding", args)
+		}
+		buf := thrift.NewTMemoryBuffer()
+		_, _ = buf.Write(payload)
+		return s.Read(thrift.NewTBinaryProtocol(buf, true, true))
+	}
+	a, ok := args.(protoArgs)
+	if !ok {
+		return fmt.Errorf("args %T do not support protobuf encoding", args)
+	}
+	return a.Unmarshal(payload)
+}
+
+func marshalGRPCResult(result interface{}, thriftCodec bool) ([]byte, error) {
+	if thriftCodec {
+		s, ok := result.(thrift.TStruct)
+		if !ok {
+			return nil, fmt.Errorf("result %T does not support thrift encoding", result)
+		}
+		buf := thrift.NewTMemoryBuffer()
+		if err := s.Write(thrift.NewTBinaryProtocol(buf, true, true)); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	res, ok := result.(protoResult)
+	if !ok {
+		return nil, fmt.Errorf("result %T does not support protobuf encoding", result)
+	}
+	return res.Marshal(nil)
+}
+
+// writeGRPCStatus 以 Trailers-Only 形式返回错误状态
+func writeGRPCStatus(w http.ResponseWriter, code codes.Code, msg string) {
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+	w.Header().Set("Grpc-Message", encodeGRPCMessage(msg))
+	w.WriteHeader(http.StatusOK)
+}
+
+// encodeGRPCMessage 按 gRPC over HTTP/2 规范对 Grpc-Message 做 percent-encoding：
+// 可打印 ASCII（0x20-0x7E）中除 '%' 外的字符原样保留，其余字节（含 UTF-8 编码的多字节字符）编码为 %XX
+func encodeGRPCMessage(msg string) string {
+	const hex = "0123456789ABCDEF"
+	var sb strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hex[c>>4])
+		sb.WriteByte(hex[c&0xf])
+	}
+	return sb.String()
+}
+
+// grpcCode 把 ErrorMapper 给出的 HTTP 状态码转换为 gRPC 状态码
+func grpcCode(status int) codes.Code {
+	switch status {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if status >= 500 {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+func (h *HTTP2Handler) Read(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return ctx, errors.New("http2: streams are served in OnRead")
+}
+
+func (h *HTTP2Handler) Write(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
+	return ctx, errors.New("http2: streams are served in OnRead")
+}
+
+func (h *HTTP2Handler) OnInactive(ctx context.Context, conn net.Conn) {}
+
+func (h *HTTP2Handler) OnError(ctx context.Context, err error, conn net.Conn) {}
+
+func (h *HTTP2Handler) OnMessage(ctx context.Context, args, result remote.Message) (context.Context, error) {
+	return h.core.OnMessage(ctx, args, result)
+}
+
+func (h *HTTP2Handler) SetPipeline(pipeline *remote.TransPipeline) {
+	h.core.SetPipeline(pipeline)
+}
+
+func (h *HTTP2Handler) SetInvokeHandleFunc(endpoint endpoint.Endpoint) {
+	h.core.SetInvokeHandleFunc(endpoint)
+}
+
+// GracefulShutdown 在服务关闭时停止 IDL 热加载
+func (h *HTTP2Handler) GracefulShutdown(ctx context.Context) error {
+	return h.core.GracefulShutdown(ctx)
+}
+
+func (h *HTTP2Handler) OnActive(ctx context.Context, conn net.Conn) (context.Context, error) {
+	return ctx, nil
+}
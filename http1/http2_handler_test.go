@@ -0,0 +1,145 @@
+package http1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/kitex/pkg/remote/trans/nphttp2/codes"
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability/stservice"
+)
+
+func TestSplitGRPCPath(t *testing.T) {
+	svc, method, ok := splitGRPCPath("/stability.STService/testSTReq")
+	assert.True(t, ok)
+	assert.Equal(t, "STService", svc)
+	assert.Equal(t, "testSTReq", method)
+
+	svc, _, ok = splitGRPCPath("/STService/testSTReq")
+	assert.True(t, ok)
+	assert.Equal(t, "STService", svc)
+
+	for _, p := range []string{"/", "/STService", "/STService/", "/a/b/c"} {
+		_, _, ok = splitGRPCPath(p)
+		assert.False(t, ok, p)
+	}
+}
+
+func grpcFrame(flag byte, payload []byte) []byte {
+	frame := make([]byte, grpcHeaderSize, grpcHeaderSize+len(payload))
+	frame[0] = flag
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func TestReadGRPCMessage(t *testing.T) {
+	payload, err := readGRPCMessage(bytes.NewReader(grpcFrame(0, []byte("abc"))), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(payload))
+
+	_, err = readGRPCMessage(bytes.NewReader(grpcFrame(1, []byte("abc"))), 10)
+	assert.ErrorIs(t, err, ErrUnsupportedTransferCoding)
+
+	_, err = readGRPCMessage(bytes.NewReader(grpcFrame(0, []byte("abcdef"))), 4)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+
+	_, err = readGRPCMessage(bytes.NewReader(grpcFrame(0, []byte("abc"))[:6]), 10)
+	assert.ErrorIs(t, err, ErrInvalidBody)
+}
+
+func TestGRPCThriftCodec(t *testing.T) {
+	name := "bob"
+	args := stability.NewSTServiceTestSTReqArgs()
+	args.Req = &stability.STRequest{Name: &name}
+
+	payload, err := marshalGRPCResult(args, true)
+	assert.NoError(t, err)
+	decoded := stability.NewSTServiceTestSTReqArgs()
+	assert.NoError(t, unmarshalGRPCArgs(decoded, payload, true))
+	assert.Equal(t, "bob", decoded.Req.GetName())
+
+	// Thrift 生成代码没有 protobuf 的 Marshal/Unmarshal
+	assert.Error(t, unmarshalGRPCArgs(decoded, payload, false))
+}
+
+func TestGRPCCode(t *testing.T) {
+	assert.Equal(t, codes.InvalidArgument, grpcCode(400))
+	assert.Equal(t, codes.Unimplemented, grpcCode(404))
+	assert.Equal(t, codes.ResourceExhausted, grpcCode(413))
+	assert.Equal(t, codes.Internal, grpcCode(500))
+	assert.Equal(t, codes.Unknown, grpcCode(418))
+}
+
+func TestEncodeGRPCMessage(t *testing.T) {
+	assert.Equal(t, "unknown method /a.B/c", encodeGRPCMessage("unknown method /a.B/c"))
+	assert.Equal(t, "100%25 done%0D%0Anext", encodeGRPCMessage("100% done\r\nnext"))
+	assert.Equal(t, "%E5%A4%B1%E8%B4%A5", encodeGRPCMessage("失败"))
+	assert.Equal(t, "", encodeGRPCMessage(""))
+
+	w := httptest.NewRecorder()
+	writeGRPCStatus(w, codes.Internal, "bad\nvalue")
+	assert.Equal(t, "13", w.Header().Get("Grpc-Status"))
+	assert.Equal(t, "bad%0Avalue", w.Header().Get("Grpc-Message"))
+}
+
+// grpcTestSearcher 只提供 STService
+type grpcTestSearcher struct{ svcInfo *serviceinfo.ServiceInfo }
+
+func (s grpcTestSearcher) SearchService(svcName, methodName string, strict bool) *serviceinfo.ServiceInfo {
+	if svcName == s.svcInfo.ServiceName {
+		return s.svcInfo
+	}
+	return nil
+}
+
+func TestServeGRPC_MalformedArgs(t *testing.T) {
+	opt := &remote.ServerOption{SvcSearcher: grpcTestSearcher{svcInfo: stservice.NewServiceInfo()}}
+	h, err := NewSvrTransHandlerFactory().HTTP2().NewTransHandler(opt)
+	assert.NoError(t, err)
+
+	// 帧长度正确但内容不是合法的 Thrift binary
+	frame := []byte{0, 0, 0, 0, 3, 0xff, 0xff, 0xff}
+	r := httptest.NewRequest("POST", "/stability.STService/testSTReq", bytes.NewReader(frame))
+	r.Header.Set("Content-Type", "application/grpc+thrift")
+	w := httptest.NewRecorder()
+	conn, peer := net.Pipe()
+	defer conn.Close()
+	defer peer.Close()
+	h.(*HTTP2Handler).serveGRPC(conn, w, r)
+	assert.Equal(t, "3", w.Header().Get("Grpc-Status"))
+}
+
+func TestNewRequestFromHTTP(t *testing.T) {
+	r := httptest.NewRequest("POST", "/api/STService/testSTReq?framework=kitex", strings.NewReader(`{"Name":"a"}`))
+	r.Header.Add("X-Tag", "a")
+	r.Header.Add("X-Tag", "b")
+	req, err := newRequestFromHTTP(r, 1024, 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, "POST", req.method)
+	assert.Equal(t, "/api/STService/testSTReq", req.path)
+	assert.Equal(t, "framework=kitex", req.rawQuery)
+	assert.Equal(t, "a, b", req.headers["X-Tag"])
+	assert.Equal(t, `{"Name":"a"}`, string(req.body))
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{"Name":"too long"}`))
+	_, err = newRequestFromHTTP(r, 4, 4)
+	assert.ErrorIs(t, err, ErrBodyTooLarge)
+}
+
+func TestHTTP2Factory_SharedCore(t *testing.T) {
+	f := NewSvrTransHandlerFactory()
+	h1, err := f.NewTransHandler(&remote.ServerOption{})
+	assert.NoError(t, err)
+	h2, err := f.HTTP2().NewTransHandler(&remote.ServerOption{})
+	assert.NoError(t, err)
+	// HTTP/1 与 HTTP/2 共用路由表、IDL 热加载与泛化客户端
+	assert.Same(t, h1.(*HTTP1Handler).bridge, h2.(*HTTP2Handler).core.bridge)
+}
@@ -1,26 +1,23 @@
 package http1
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/protocol"
 	"github.com/bytedance/gopkg/cloud/metainfo"
-	"reflect"
-
-	//"github.com/bytedance/gopkg/cloud/metainfo"
 	"github.com/cloudwego/kitex/pkg/endpoint"
 	"github.com/cloudwego/kitex/pkg/klog"
 	"github.com/cloudwego/kitex/pkg/remote"
 	"github.com/cloudwego/kitex/pkg/remote/transmeta"
 	"github.com/cloudwego/kitex/pkg/rpcinfo"
 	"github.com/cloudwego/kitex/pkg/serviceinfo"
-	"github.com/cloudwego/netpoll"
 	"net"
-	"regexp"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
 )
 
 // +------------------------------------------------------------+
@@ -49,7 +46,7 @@ import (
 //   | - 读取 HTTP 请求数据                                             |
 //   | - 解析请求行 /api/Service/Method → 设置 msg.ServiceName/Method |
 //   | - 解析 JSON Body → Thrift struct → 设置 msg.Args                |
-//   | - TODO: 解析 Header/Query 参数 → 映射至字段（关键路径 5）         |
+//   | - 解析 Header/Query/Cookie 参数 → 按 tag 映射至字段（关键路径 5） |
 //   | - 设置 msg.MessageType = remote.Call                           |
 //   | - 调用 h.handler(ctx, msg) 执行 Kitex 调用链（关键路径 7）       |
 //   +-----------------------------------------------------------------+
@@ -62,7 +59,7 @@ import (
 //                                         v
 //   +-----------------------------------------------------------------+
 //   | Write(ctx, conn, msg)                                           |  // ✅ 关键路径 4 & 6：统一响应封装
-//   | - 判断是否为 BizError / 系统异常                                 |
+//   | - 判断是否为 BizError / 系统异常，经 ErrorMapper 映射 HTTP 状态码 |
 //   | - 构造响应 JSON：{code, message, data}                         |
 //   | - 设置 HTTP Header（Content-Type、Length）并写回 conn           |
 //   +-----------------------------------------------------------------+
@@ -85,13 +82,33 @@ type JsonResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-var httpPattern = regexp.MustCompile(`^(?:GET |POST|PUT|DELE|HEAD|OPTI|CONN|TRAC|PATC)$`)
+type HTTP1SvrTransHandlerFactory struct {
+	opts []Option
 
-type HTTP1SvrTransHandlerFactory struct{}
+	// 同一个工厂创建的所有 handler 共用一份 bridge，autodetect 在 TLS、PROXY 等每一层都会调用 NewTransHandler
+	once   sync.Once
+	bridge *bridge
+	err    error
+}
+
+// NewSvrTransHandlerFactory 创建带配置项的 HTTP 处理器工厂；零值的 HTTP1SvrTransHandlerFactory 使用默认配置
+func NewSvrTransHandlerFactory(opts ...Option) *HTTP1SvrTransHandlerFactory {
+	return &HTTP1SvrTransHandlerFactory{opts: opts}
+}
 
 // NewTransHandler 是 Kitex 要求实现的工厂方法，用于创建一个 ServerTransHandler（即协议处理器）实例。
 // opt 参数是框架在初始化阶段提供的服务上下文信息，包括服务结构、配置、结果工厂等。
 func (f *HTTP1SvrTransHandlerFactory) NewTransHandler(opt *remote.ServerOption) (remote.ServerTransHandler, error) {
+	f.once.Do(func() {
+		var searcher remote.ServiceSearcher
+		if opt != nil {
+			searcher = opt.SvcSearcher
+		}
+		f.bridge, f.err = newBridge(newOptions(f.opts), searcher)
+	})
+	if f.err != nil {
+		return nil, f.err
+	}
 	return &HTTP1Handler{
 		// 表示当前服务的元信息（如服务名、方法名、IDL 等）
 		svcInfo: opt.TargetSvcInfo,
@@ -99,9 +116,82 @@ func (f *HTTP1SvrTransHandlerFactory) NewTransHandler(opt *remote.ServerOption)
 		svcSearcher: opt.SvcSearcher,
 		// 保存整个服务配置上下文（包含 Payload 编解码器、错误处理器等）
 		opt: opt,
+		// 配置项、路由表与泛化服务
+		bridge: f.bridge,
 	}, nil
 }
 
+// bridge 是 HTTP 桥接与连接无关的状态：配置项、由 IDL 构造的路由表和泛化客户端，
+// 开启热加载时由唯一的 watcher 更新，服务关闭时停止
+type bridge struct {
+	// HTTP 桥接的配置项，如请求体大小上限
+	options *Options
+	// 按 HTTP 方法 + 路径模板匹配 Kitex 服务方法的路由表，以及由 IDL 字段上 vt.* 与 api.* 注解构造的
+	// 校验规则与参数来源；IDL 热加载时整体替换
+	table atomic.Pointer[routeTable]
+	// REST 路由、校验规则与字段来源的 IDL，热加载时据此重建
+	idl *idlCatalog
+	// 按服务名索引的泛化客户端，命中的请求直接转发给下游服务
+	generic map[string]*genericTarget
+	// 已注册服务的查找器，用于检查 IDL 注解能否与生成代码对应
+	svcSearcher remote.ServiceSearcher
+
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func newBridge(options *Options, svcSearcher remote.ServiceSearcher) (*bridge, error) {
+	if options.MaxFormPartSize > options.MaxBodySize {
+		return nil, fmt.Errorf("max form part size %d exceeds max body size %d", options.MaxFormPartSize, options.MaxBodySize)
+	}
+	if options.MaxFormPartSize == 0 {
+		options.MaxFormPartSize = min(defaultMaxFormPartSize, options.MaxBodySize)
+	}
+	catalog, err := newIDLCatalog(options.Routes, options.IDLRoutes)
+	if err != nil {
+		return nil, err
+	}
+	table, err := catalog.build(options.Validate, nil)
+	if err != nil {
+		return nil, err
+	}
+	genericTargets, err := newGenericTargets(options.generic)
+	if err != nil {
+		return nil, err
+	}
+	b := &bridge{options: options, idl: catalog, generic: genericTargets, svcSearcher: svcSearcher, done: make(chan struct{})}
+	b.table.Store(table)
+	b.warnUnmatched(table)
+	if options.IDLReloadInterval > 0 {
+		go b.watchIDL(options.IDLReloadInterval)
+	}
+	return b, nil
+}
+
+// warnUnmatched 对带 vt.* 或 api.* 注解、却与已注册服务中任何生成类型都不对应的 IDL struct 打印警告，
+// 这些注解不会生效，通常是 namespace go 或 struct 名称与生成代码不一致
+func (b *bridge) warnUnmatched(t *routeTable) {
+	keys := make([]structKey, 0, len(t.bindings))
+	for k := range t.bindings {
+		keys = append(keys, k)
+	}
+	if t.validator != nil {
+		for k := range t.validator.structs {
+			if t.bindings[k] == nil {
+				keys = append(keys, k)
+			}
+		}
+	}
+	for _, k := range unmatchedStructs(b.svcSearcher, t.services, keys) {
+		klog.Warnf("KITEX: IDL struct %s has field annotations but matches no generated type of the registered services, ignored", k)
+	}
+}
+
+// stop 停止 IDL 热加载，可以重复调用
+func (b *bridge) stop() {
+	b.stopOnce.Do(func() { close(b.done) })
+}
+
 type HTTP1Handler struct {
 	// 当前服务的元信息（服务名、方法名、IDL 结构）
 	svcInfo *serviceinfo.ServiceInfo
@@ -109,70 +199,63 @@ type HTTP1Handler struct {
 	svcSearcher remote.ServiceSearcher
 	// 完整的 ServerOption 配置上下文，用于读取 Codec、错误处理、ResultProvider 等
 	opt *remote.ServerOption
+	// 同一个工厂创建的 handler 共用的配置项、路由表与泛化服务
+	*bridge
 	// 在 SetPipeline() 中注入，用于调度 Read → OnMessage → Write 的框架处理管道
 	transPipe   *remote.TransPipeline
 	handlerFunc endpoint.Endpoint
 }
 
 func (h *HTTP1Handler) ProtocolMatch(ctx context.Context, conn net.Conn) error {
-	c, ok := conn.(netpoll.Connection)
-	if ok {
-		pre, _ := c.Reader().Peek(4)
-		if httpPattern.Match(pre) {
-			return nil
-		}
+	if s, ok := protocol.LookupSniffer(protocol.ProtocolHttp); ok && s.MatchConn(conn) {
+		return nil
 	}
 	return errors.New("error protocol not match")
 }
 
 // 解析 HTTP 请求并转为 Kitex RPC 调用
 func (h *HTTP1Handler) Read(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
-	fmt.Println("HelloWorld")
 	// ---------------------------------------------------------
-	// 1: 利用 parser.parseRequestLine() 解析请求行
-	// - 获取 method / serviceName / methodName
-	// - 校验 path 格式为 /api/{Service}/{Method}
+	// 1: 从连接级 reader 中读取一个完整请求
+	// - 请求行：获取 method / path，按路由表或 /api/{Service}/{Method} 约定确定服务方法
+	// - Header：存入 headers map，并据此判断 keep-alive
+	// - Body：按 Content-Length 精准读取，或解码 Transfer-Encoding: chunked
+	// reader 跨请求复用，流水线请求的剩余字节会保留到下一次 Read
 	// ---------------------------------------------------------
-	// 创建带缓冲的 Reader，并封装为 netpoll.Reader
-	bufReader := bufio.NewReader(conn)
-	reader := netpoll.NewReader(bufReader)
-
-	// 使用 parser.go 中的 parseRequestLine 方法
-	method, serviceName, methodName, err := parseRequestLine(reader)
-	klog.Infof("HTTP1 request line parsed: method=%s, service=%s, method=%s", method, serviceName, methodName)
-	if err != nil {
-		return ctx, fmt.Errorf("failed to parse request line: %w", err)
+	cs := connStateFromContext(ctx)
+	if cs == nil {
+		return ctx, errors.New("http1 connection state not found in ctx")
 	}
-	// ---------------------------------------------------------
-	// 2: 利用 parser.parseHeaders() 获取 Header Map
-	// - Content-Length 字段必须存在且合法
-	// - 所有 header 存入 headers map[string]string
-	// ---------------------------------------------------------
-	_, contentLength, err := parseHeaders(reader)
+	req, err := readRequest(cs.reader, readLimits{
+		headerBytes: h.options.MaxHeaderBytes, body: h.options.MaxBodySize, formPart: h.options.MaxFormPartSize,
+	})
 	if err != nil {
-		return ctx, fmt.Errorf("failed to parse headers: %w", err)
+		return ctx, fmt.Errorf("failed to read http request: %w", err)
 	}
-	// ---------------------------------------------------------
-	// 3: 利用 reader.Next(n) 精准读取 JSON body
-	// - Content-Length 决定 body 大小
-	// - 返回值为 []byte 类型 JSON
-	// ---------------------------------------------------------
-	//if contentLength <= 0 || contentLength > 10*1024*1024 { // 限制最大 10MB
-	//	return ctx, fmt.Errorf("invalid content length: %d", contentLength)
-	//}
-	bodyBytes, err := reader.Next(contentLength)
-	fmt.Println(string(bodyBytes))
-	if err != nil {
-		return ctx, fmt.Errorf("failed to read body: %w", err)
+	cs.req = req
+	if h.isWebSocketUpgrade(req) {
+		return ctx, errWebSocketUpgrade
+	}
+	if isThriftContentType(headerValue(req.headers, "Content-Type")) {
+		return ctx, errThriftOverHTTP
 	}
+	if err := h.resolveRoute(req); err != nil {
+		return ctx, err
+	}
+	if h.isGeneric(req) {
+		return ctx, errGenericCall
+	}
+	klog.CtxDebugf(ctx, "HTTP1 request parsed: method=%s, service=%s, method=%s", req.method, req.serviceName, req.methodName)
+
+	// ---------------------------------------------------------
+	// 2: 将 path/header 映射为 Kitex 元信息
 	// ---------------------------------------------------------
-	// 4: 将 path/header 映射为 Kitex 元信息
 	msg.SetMessageType(remote.Call)
 
 	// 将 service 和 method 注入到 TransInfo 中
 	msg.TransInfo().PutTransIntInfo(map[uint16]string{
-		transmeta.ToService: serviceName,
-		transmeta.ToMethod:  methodName,
+		transmeta.ToService: req.serviceName,
+		transmeta.ToMethod:  req.methodName,
 	})
 
 	// 将 header 中的一些字段透传（例如 X-Trace-ID）
@@ -182,28 +265,9 @@ func (h *HTTP1Handler) Read(ctx context.Context, conn net.Conn, msg remote.Messa
 		msg.TransInfo().PutTransStrInfo(metaMap)
 	}
 
-	// 5: JSON body → Thrift 请求 struct
-	//fmt.Println(msg.Data())
-	//args := msg.Data().(*stability.STRequest)
-
-	//ss := ""
-	//// JSON 反序列化
-	//if err := json.Unmarshal(bodyBytes, &ss); err != nil {
-	//	return ctx, fmt.Errorf("failed to unmarshal body to thrift args: %w", err)
-	//}
-	//msg.NewData(ss)
-	svcInfo := h.opt.SvcSearcher.SearchService(serviceName, methodName, true)
-	mtInfo, ok := svcInfo.Methods[methodName]
-	if !ok {
-		return ctx, fmt.Errorf("method not found: %s", mtInfo)
-	}
-	args := mtInfo.NewArgs()
-	//if err := BindJSONToArgs(msg, methodName, bodyBytes); err != nil {
-	//	return ctx, fmt.Errorf("bind JSON to thrift args failed: %w", err)
-	//}
-
-	if json.Unmarshal(bodyBytes, args) != nil {
-		return ctx, fmt.Errorf("failed to unmarshal body: %w", err)
+	mtInfo, args, err := h.prepareArgs(msg.RPCInfo(), req)
+	if err != nil {
+		return ctx, err
 	}
 
 	ctx = context.WithValue(ctx, "http_args", args)
@@ -212,132 +276,279 @@ func (h *HTTP1Handler) Read(ctx context.Context, conn net.Conn, msg remote.Messa
 	return ctx, nil
 }
 
-// 方法名到请求结构体构造器的注册表
-var methodArgType = map[string]func() interface{}{
-	"testSTReq": func() interface{} { return &stability.STRequest{} },
-	// 可添加更多方法注册： "getUser": func() interface{} { return &user.GetUserRequest{} },
-}
+// prepareArgs 查找请求对应的服务方法，填充 RPCInfo，并把 Body 与各类参数转换为 Thrift 请求 struct。
+// HTTP/1 与 HTTP/2 共用这部分逻辑
+func (h *HTTP1Handler) prepareArgs(ri rpcinfo.RPCInfo, req *request) (serviceinfo.MethodInfo, interface{}, error) {
+	svcInfo := h.opt.SvcSearcher.SearchService(req.serviceName, req.methodName, true)
+	if svcInfo == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrServiceNotFound, req.serviceName)
+	}
+	mtInfo := svcInfo.MethodInfo(req.methodName)
+	if mtInfo == nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrMethodNotFound, req.methodName)
+	}
+	setInvocation(ri, svcInfo, req.methodName)
 
-// BindJSONToArgs 将 JSON body 反序列化为对应 method 的 Thrift 参数结构体，并注入到 msg.Data()
-func BindJSONToArgs(msg remote.Message, methodName string, body []byte) (interface{}, error) {
-	// 查找结构体构造函数
-	constructor, ok := methodArgType[methodName]
-	if !ok {
-		return nil, fmt.Errorf("BindJSONToArgs: unsupported method: %s", methodName)
+	// ---------------------------------------------------------
+	// 3: JSON body → Thrift 请求 struct
+	// ---------------------------------------------------------
+	// 字段名使用 IDL 名称；单参数方法的 Body 即为该参数本身。
+	// 没有 Body 的请求（如 GET、DELETE）参数完全来自 Query/Header 等绑定
+	// 带 api.raw_body 字段的方法允许非 JSON 的 Body
+	table := h.table.Load()
+	args := mtInfo.NewArgs()
+	if len(req.body) > 0 && !isFormBody(headerValue(req.headers, "Content-Type")) &&
+		(json.Valid(req.body) || !table.bindings.hasRawBody(args)) {
+		if err := decodeArgs(req.body, args, h.options.JSON); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+		}
 	}
 
-	// 构造结构体实例
-	args := constructor()
+	// ---------------------------------------------------------
+	// 4: Header/Query/Cookie/Form 参数 → 带对应 tag 或 api.* 注解的 Thrift 字段
+	// ---------------------------------------------------------
+	// 表单 Body 的字段按 IDL 字段名绑定，multipart 的文件内容可以绑定到 binary 字段
+	src := newBindingSource(req)
+	form, err := req.form, req.formErr
+	if form == nil && err == nil {
+		form, err = parseForm(src.contentType, req.body, h.options.MaxFormPartSize)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	src.form = form
+	src.api, src.conv = table.bindings, h.options.JSON
+	// 无 Body 时没有 tag 的字段按 JSON 字段名从 Query 中取值，便于浏览器直接 GET
+	src.queryFallback = len(req.body) == 0 && req.form == nil
+	if err := bindArgs(args, src); err != nil {
+		return nil, nil, fmt.Errorf("failed to bind request params: %w", err)
+	}
 
-	// 反序列化 JSON 到 struct
-	if err := json.Unmarshal(body, args); err != nil {
-		return nil, fmt.Errorf("BindJSONToArgs: json unmarshal failed: %w", err)
+	// ---------------------------------------------------------
+	// 5: 按 IDL 中的 vt.* 注解校验参数，handler 只会收到合法的输入
+	// ---------------------------------------------------------
+	if err := table.validator.validate(args); err != nil {
+		return nil, nil, err
 	}
+	return mtInfo, args, nil
+}
 
-	// 通过反射把 args 的值设置到 msg.Data()
-	if msg.Data() != nil {
-		vDst := reflect.ValueOf(msg.Data()).Elem()
-		vSrc := reflect.ValueOf(args).Elem()
-		vDst.Set(vSrc)
+// invoke 执行一次已确定服务方法的 JSON 调用，返回交给 ResponseEncoder 的结果：泛化服务转发给下游，
+// 其余经过本地 Kitex endpoint。HTTP/2 与 WebSocket 共用，HTTP/1 的本地调用由 TransPipeline 完成
+func (h *HTTP1Handler) invoke(ctx context.Context, ri rpcinfo.RPCInfo, req *request) (interface{}, error) {
+	if h.isGeneric(req) {
+		return h.callGeneric(ctx, req)
 	}
+	mtInfo, args, err := h.prepareArgs(ri, req)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	if !mtInfo.OneWay() {
+		result = mtInfo.NewResult()
+	}
+	if err := h.handlerFunc(ctx, args, result); err != nil {
+		return nil, err
+	}
+	if bizErr := ri.Invocation().BizStatusErr(); bizErr != nil {
+		return nil, bizErr
+	}
+	return unwrapResult(result), nil
+}
+
+// setInvocation 填充 RPCInfo，服务端 endpoint 依赖 Invocation 中的服务名和方法名进行分发
+func setInvocation(ri rpcinfo.RPCInfo, svcInfo *serviceinfo.ServiceInfo, methodName string) {
+	if ri == nil {
+		return
+	}
+	if setter, ok := ri.Invocation().(rpcinfo.InvocationSetter); ok {
+		setter.SetPackageName(svcInfo.GetPackageName())
+		setter.SetServiceName(svcInfo.ServiceName)
+		setter.SetMethodName(methodName)
+	}
+	if to := rpcinfo.AsMutableEndpointInfo(ri.To()); to != nil {
+		_ = to.SetMethod(methodName)
+	}
+}
 
-	return args, nil
+// resolveRoute 确定请求对应的服务名和方法名：优先匹配路由表，未命中时使用 /api/{Service}/{Method} 约定。
+// 路径存在但 HTTP 方法不被接受时返回 MethodNotAllowedError。
+func (h *HTTP1Handler) resolveRoute(req *request) error {
+	if _, err := url.PathUnescape(req.path); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPathEscape, err)
+	}
+	router := h.table.Load().router
+	if route, params := router.Match(req.method, req.path); route != nil {
+		req.serviceName, req.methodName, req.pathParams = route.ServiceName, route.MethodName, params
+	} else if allow := router.AllowedMethods(req.path); len(allow) > 0 {
+		return &MethodNotAllowedError{Method: req.method, Allow: allow}
+	} else {
+		serviceName, methodName, err := resolveAPIPath(req.path)
+		if err != nil {
+			return err
+		}
+		req.serviceName, req.methodName = serviceName, methodName
+	}
+	return h.checkVerb(req)
+}
+
+// checkVerb 按 WithMethodVerbs 检查服务方法是否接受请求的 HTTP 方法
+func (h *HTTP1Handler) checkVerb(req *request) error {
+	if verbs, ok := h.options.MethodVerbs[req.serviceName+"."+req.methodName]; ok {
+		for _, v := range verbs {
+			if v == req.method {
+				return nil
+			}
+		}
+		return &MethodNotAllowedError{Method: req.method, Allow: verbs}
+	}
+	return nil
 }
 
 // 将 Kitex RPC 返回结果封装为标准 HTTP JSON 响应
 func (h *HTTP1Handler) Write(ctx context.Context, conn net.Conn, msg remote.Message) (context.Context, error) {
-	fmt.Println("HelloWorldzzzz")
-	// 1: 判断调用结果是正常返回还是异常
-	var (
-		code    int32
-		message string
-		data    interface{}
-	)
+	cs := connStateFromContext(ctx)
+	if cs == nil {
+		cs = newConnState(conn)
+	}
+
+	// 1: 判断调用结果是正常返回还是异常，异常交给 ErrorMapper 转换为对应的 HTTP 状态码
 	rpcInfo := msg.RPCInfo()
+	var callErr error
 	if bizErr := rpcInfo.Invocation().BizStatusErr(); bizErr != nil {
-		code = bizErr.BizStatusCode()
-		message = bizErr.BizMessage()
-		data = nil
+		callErr = bizErr
 	} else if sysErr := rpcInfo.Stats().Error(); sysErr != nil {
-		code = 500
-		message = "internal error"
-		data = nil
-	} else {
-		code = 200
-		message = "success"
-		data = msg.Data()
-		fmt.Println("sb")
+		callErr = sysErr
 	}
-
-	// 2: 构造标准 JSON 响应结构：
-	resp := JsonResponse{
-		Code:    code,
-		Message: message,
-		Data:    data,
+	if callErr != nil {
+		return ctx, h.writeError(ctx, cs, h.options.ErrorMapper.MapError(ctx, callErr))
 	}
 
-	// 3: 根据错误类型构造不同 code/message：
-	jsonBody, err := json.Marshal(resp)
-	if err != nil {
+	// 2: 构造响应结构：默认为 {code, message, data} 信封，可按方法替换
+	resp := h.responseEncoder(cs.req).EncodeSuccess(ctx, unwrapResult(msg.Data()))
+
+	// 3: 构造 HTTP 响应头：HTTP/1.1 200 OK + Content-Type: application/json
+	// 结果较小时带 Content-Length 一次写出，超过阈值时自动切换为 chunked 流式输出
+	rw := newResponseWriter(cs.writer, cs.req, h.options.ChunkedThreshold)
+	rw.AddHeader("Content-Type", "application/json")
+
+	// 4: 将响应 JSON 增量编码到 responseWriter，避免大结果在内存中保留两份
+	if err := newJSONStreamEncoder(rw, h.options.JSON).Encode(resp); err != nil {
+		klog.CtxErrorf(ctx, "HTTP1 encode response failed: %v", err)
+		// 响应头已经写出时无法再改写响应，只能关闭连接让客户端感知到异常
+		if !rw.Reset() {
+			return ctx, err
+		}
 		// 如果 JSON 编码失败（极少见，一般是结构体含非法类型）
 		// 构造兜底 JSON 响应，防止崩溃
-		jsonBody = []byte(`{"code":500,"message":"json encode error","data":null}`)
+		rw.SetStatus(http.StatusInternalServerError)
+		_, _ = rw.Write([]byte(`{"code":500,"message":"json encode error","data":null}`))
 	}
 
-	// 4: 构造 HTTP 响应头 响应头：HTTP/1.1 200 OK + Content-Type: application/json + Content-Length
-	var buf bytes.Buffer
-
-	// 写响应行和头部
-	buf.WriteString("HTTP/1.1 200 OK\r\n")
-	buf.WriteString("Content-Type: application/json\r\n")
-	buf.WriteString(fmt.Sprintf("Content-Length: %d\r\n", len(jsonBody)))
-	buf.WriteString("Connection: keep-alive\r\n")
-
-	// 空行分隔 header 和 body
-	buf.WriteString("\r\n")
-	// 5: 构造完整 HTTP 响应字符串
-	// - 响应体：json 数据
-	buf.Write(jsonBody)
-
-	// 6: 写入 conn（用 conn.Write(...) 输出响应）
-	_, err = conn.Write(buf.Bytes())
-	if err != nil {
-		return nil, err
+	// 5: 写入 conn 并刷新
+	if err := rw.Finish(); err != nil {
+		return ctx, err
 	}
 	// 最终效果：HTTP 客户端收到标准 JSON 格式响应，与 REST 服务一致
 	return ctx, nil
 }
 
-func (h *HTTP1Handler) OnRead(ctx context.Context, conn net.Conn) error {
+// responseEncoder 返回请求对应方法的 ResponseEncoder，未单独指定时使用全局配置
+func (h *HTTP1Handler) responseEncoder(req *request) ResponseEncoder {
+	if req != nil {
+		if enc, ok := h.options.ResponseEncoders[req.serviceName+"."+req.methodName]; ok {
+			return enc
+		}
+	}
+	return h.options.ResponseEncoder
+}
 
-	fmt.Println("---->OnREAD")
-	// 1. 创建 RPCInfo（包含服务名、方法名、调用信息等）
+// OnRead 是连接级的请求循环：每次可读事件中依次处理 reader 里已缓冲的全部请求，
+// 保证流水线请求按顺序应答；遇到 Connection: close 或 HTTP/1.0 短连接时在响应后关闭连接。
+func (h *HTTP1Handler) OnRead(ctx context.Context, conn net.Conn) error {
+	cs := connStateFromContext(ctx)
+	if cs == nil {
+		// 未经过 OnActive 的连接（如直接调用 OnRead），补建连接状态
+		cs = newConnState(conn)
+		ctx = withConnState(ctx, cs)
+	}
+	for {
+		if err := h.serveRequest(ctx, conn, cs); err != nil {
+			return err
+		}
+		if !cs.req.keepAlive {
+			return conn.Close()
+		}
+		// reader 中没有剩余数据时等待下一次可读事件
+		if cs.reader.Len() == 0 {
+			return nil
+		}
+	}
+}
 
-	rpcInfo := rpcinfo.NewRPCInfo(
-		rpcinfo.NewEndpointInfo("", "", nil, nil), // from
-		rpcinfo.NewEndpointInfo("", "", nil, nil), // to
-		rpcinfo.NewInvocation("", ""),             // 空调用，后续 Read 中会填充
-		rpcinfo.NewRPCConfig(),
-		rpcinfo.NewRPCStats(),
-	)
+// serveRequest 处理连接上的单个请求：Read → OnMessage → Write
+func (h *HTTP1Handler) serveRequest(ctx context.Context, conn net.Conn, cs *connState) (err error) {
+	// 1. 每个请求创建独立的 RPCInfo（包含服务名、方法名、调用信息等），并注入 ctx
+	ri := h.newRPCInfo(conn)
+	ctx = rpcinfo.NewCtxWithRPCInfo(ctx, ri)
+	defer cs.reader.Release()
 
 	// 2. 构造请求 msg（类型是 remote.Call），用来承载请求数据
-	req := remote.NewMessageWithNewer(h.svcInfo, h.svcSearcher, rpcInfo, remote.Call, remote.Server)
+	req := remote.NewMessageWithNewer(h.svcInfo, h.svcSearcher, ri, remote.Call, remote.Server)
 	req.SetPayloadCodec(h.opt.PayloadCodec)
-	res := remote.NewMessage(nil, h.svcInfo, rpcInfo, remote.Reply, remote.Server)
-	var err error
+	defer remote.RecycleMessage(req)
+	cs.req = nil
 	ctx, err = h.transPipe.Read(ctx, conn, req)
+	if errors.Is(err, errWebSocketUpgrade) {
+		return h.serveWebSocket(ctx, conn, cs)
+	}
+	if errors.Is(err, errThriftOverHTTP) {
+		return h.serveThriftHTTP(ctx, conn, cs)
+	}
+	if errors.Is(err, errGenericCall) {
+		return h.serveGeneric(ctx, cs)
+	}
 	if err != nil {
-		return err
+		if cs.req == nil {
+			// 连接已断开等读取错误无法应答，直接关闭连接
+			if !isRequestError(err) {
+				return err
+			}
+			// 报文格式错误时报文边界已不可信，应答后关闭连接
+			cs.req = &request{proto: "HTTP/1.1", keepAlive: false}
+		}
+		// 请求已完整读取（如路由不存在、参数错误）时应答后连接仍可继续使用
+		return h.writeError(ctx, cs, h.options.ErrorMapper.MapError(ctx, err))
 	}
-	
+
+	// 3. 按方法信息构造结果 msg，业务 handler 会把返回值写入其中
+	var result interface{}
+	if mtInfo, ok := ctx.Value("method_info").(serviceinfo.MethodInfo); ok && !mtInfo.OneWay() {
+		result = mtInfo.NewResult()
+	}
+	res := remote.NewMessage(result, req.ServiceInfo(), ri, remote.Reply, remote.Server)
+	defer remote.RecycleMessage(res)
 	ctx, err = h.transPipe.OnMessage(ctx, req, res)
 	if err != nil {
-		return err
+		// 业务 handler 返回的错误不关闭连接，记录到 RPCStats 中由 Write 转为错误响应
+		rpcinfo.AsMutableRPCStats(ri.Stats()).SetError(err)
 	}
 	_, err = h.transPipe.Write(ctx, conn, res)
+	return err
+}
 
-	return nil
+// newRPCInfo 优先使用框架提供的 RPCInfo 初始化函数，保证与 Thrift 请求的 RPCInfo 一致
+func (h *HTTP1Handler) newRPCInfo(conn net.Conn) rpcinfo.RPCInfo {
+	if h.opt != nil && h.opt.InitOrResetRPCInfoFunc != nil {
+		return h.opt.InitOrResetRPCInfoFunc(nil, conn.RemoteAddr())
+	}
+	return rpcinfo.NewRPCInfo(
+		rpcinfo.NewEndpointInfo("", "", conn.RemoteAddr(), nil), // from
+		rpcinfo.NewEndpointInfo("", "", nil, nil),               // to
+		rpcinfo.NewServerInvocation(),                           // 空调用，后续 Read 中会填充
+		rpcinfo.NewRPCConfig(),
+		rpcinfo.NewRPCStats(),
+	)
 }
 
 func (h *HTTP1Handler) OnInactive(ctx context.Context, conn net.Conn) {}
@@ -347,22 +558,15 @@ func (h *HTTP1Handler) OnError(ctx context.Context, err error, conn net.Conn) {}
 func (h *HTTP1Handler) OnMessage(ctx context.Context, args, result remote.Message) (context.Context, error) {
 	// 从 ctx 拿出在 Read 中保存的参数
 	rawArgs := ctx.Value("http_args")
-	methodInfo := ctx.Value("method_info").(serviceinfo.MethodInfo)
-	//fmt.Printf(result.RPCInfo().Invocation().ServiceName())
-	//fmt.Println()
-
+	methodInfo, _ := ctx.Value("method_info").(serviceinfo.MethodInfo)
 	if rawArgs == nil || methodInfo == nil {
 		return ctx, errors.New("http_args or method_info not found in ctx")
 	}
-	//res := methodInfo.NewResult()
-
-	// 重新构建新的 Message 用于处理
-	// newArgsMsg := remote.NewMessage(rawArgs, h.svcInfo, args.RPCInfo(), remote.Call, remote.Server)
-	// newResultMsg := remote.NewMessage(res, h.svcInfo, args.RPCInfo(), remote.Reply, remote.Server)
-	err := h.handlerFunc(ctx, rawArgs, result)
 
+	// 与 Kitex 默认 handler 一致：业务 endpoint 接收 args 与 result 的具体结构体
+	err := h.handlerFunc(ctx, rawArgs, result.Data())
 	if err != nil {
-		return nil, err
+		return ctx, err
 	}
 	return ctx, nil
 }
@@ -375,6 +579,13 @@ func (h *HTTP1Handler) SetInvokeHandleFunc(endpoint endpoint.Endpoint) {
 	h.handlerFunc = endpoint
 }
 
+// GracefulShutdown 在服务关闭时停止 IDL 热加载
+func (h *HTTP1Handler) GracefulShutdown(ctx context.Context) error {
+	h.stop()
+	return nil
+}
+
+// OnActive 在协议嗅探命中后调用，为连接创建跨请求复用的解析状态
 func (h *HTTP1Handler) OnActive(ctx context.Context, conn net.Conn) (context.Context, error) {
-	return ctx, nil
+	return withConnState(ctx, newConnState(conn)), nil
 }
@@ -0,0 +1,294 @@
+package http1
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// IDL 热加载：按固定间隔检查已加载的 .thrift 文件（含 include）的修改时间与大小，
+// 变化后重新解析，路由表、校验规则与字段来源作为一个版本整体原子替换，泛化服务的描述交给 provider 原子替换；
+// 已建立的 HTTP / Thrift 连接不受影响，解析失败时记录错误并继续使用之前的版本
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// idlSnapshot 是一个主 IDL 及其 include 文件在某一时刻的内容
+type idlSnapshot struct {
+	// main 是主文件的绝对路径
+	main string
+	// contents 按绝对路径索引文件内容，include 语句按所在文件目录解析出的路径同样指向被 include 的文件
+	contents map[string]string
+	stamps   map[string]fileStamp
+}
+
+// loadIDL 解析主 IDL 及其全部 include，返回文件快照与语法树
+func loadIDL(path string, includeDirs []string) (*idlSnapshot, *parser.Thrift, error) {
+	ast, err := parser.ParseFile(path, includeDirs, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	snap := &idlSnapshot{contents: make(map[string]string), stamps: make(map[string]fileStamp)}
+	if snap.main, err = filepath.Abs(path); err != nil {
+		return nil, nil, err
+	}
+	read := func(name string) (string, error) {
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return "", err
+		}
+		if c, ok := snap.contents[abs]; ok {
+			return c, nil
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return "", err
+		}
+		b, err := os.ReadFile(abs)
+		if err != nil {
+			return "", err
+		}
+		snap.contents[abs] = string(b)
+		snap.stamps[abs] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		return string(b), nil
+	}
+	for t := range ast.DepthFirstSearch() {
+		if _, err := read(t.Filename); err != nil {
+			return nil, nil, err
+		}
+		dir, _ := filepath.Abs(filepath.Dir(t.Filename))
+		for _, inc := range t.Includes {
+			if inc.Reference == nil {
+				continue
+			}
+			c, err := read(inc.Reference.Filename)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !filepath.IsAbs(inc.Path) {
+				// 经 includeDirs 找到的文件以 include 语句所在目录下的路径再登记一次
+				snap.contents[filepath.Join(dir, inc.Path)] = c
+			}
+		}
+	}
+	return snap, ast, nil
+}
+
+// current 返回快照中各文件当前的状态，已删除的文件为零值
+func (s *idlSnapshot) current() map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(s.stamps))
+	for name := range s.stamps {
+		if info, err := os.Stat(name); err == nil {
+			stamps[name] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		} else {
+			stamps[name] = fileStamp{}
+		}
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, s := range a {
+		if t, ok := b[name]; !ok || !s.modTime.Equal(t.modTime) || s.size != t.size {
+			return false
+		}
+	}
+	return true
+}
+
+// idlVersion 记录当前生效的快照，以及最近一次解析失败时的文件状态，同一份错误内容只报告一次
+type idlVersion struct {
+	snap     *idlSnapshot
+	rejected map[string]fileStamp
+}
+
+// reload 在文件变化时调用 load，成功后替换快照，返回是否已替换
+func (v *idlVersion) reload(load func() (*idlSnapshot, error)) (bool, error) {
+	cur := v.snap.current()
+	if sameStamps(cur, v.snap.stamps) || sameStamps(cur, v.rejected) {
+		return false, nil
+	}
+	snap, err := load()
+	if err != nil {
+		v.rejected = cur
+		return false, err
+	}
+	v.snap, v.rejected = snap, nil
+	return true, nil
+}
+
+// idlRevision 是一个 IDL 文件某一版本的快照及其派生的路由、校验规则与字段来源
+type idlRevision struct {
+	snap     *idlSnapshot
+	routes   []Route
+	rules    map[structKey]map[int]*fieldRules
+	bindings apiBindings
+	// services 是 IDL 及其 include 中定义的服务名，用于检查注解 struct 能否与生成代码对应
+	services []string
+}
+
+func loadRevision(path string) (*idlRevision, error) {
+	snap, ast, err := loadIDL(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load routes from %s: %w", path, err)
+	}
+	rev := &idlRevision{snap: snap, routes: routesFromAST(ast), rules: rulesFromAST(ast), bindings: bindingsFromAST(ast)}
+	for t := range ast.DepthFirstSearch() {
+		for _, svc := range t.Services {
+			rev.services = append(rev.services, svc.Name)
+		}
+	}
+	return rev, nil
+}
+
+// idlSource 是 WithIDLRoutes 指定的一个 IDL 文件，current 只在整体重建成功后替换
+type idlSource struct {
+	path    string
+	current *idlRevision
+}
+
+// idlCatalog 汇总配置的路由与各 IDL 文件派生的路由、校验规则与字段来源，IDL 变化后整体重建
+type idlCatalog struct {
+	mu      sync.Mutex
+	static  []Route
+	sources []*idlSource
+	// rejected 是最近一次重建失败时各文件的状态，同一份错误内容只报告一次
+	rejected map[string]fileStamp
+}
+
+func newIDLCatalog(static []Route, paths []string) (*idlCatalog, error) {
+	c := &idlCatalog{static: static}
+	for _, path := range paths {
+		rev, err := loadRevision(path)
+		if err != nil {
+			return nil, err
+		}
+		c.sources = append(c.sources, &idlSource{path: path, current: rev})
+	}
+	return c, nil
+}
+
+// routeTable 是路由表与字段注解的一个完整版本，请求处理期间始终使用同一个版本
+type routeTable struct {
+	router *Router
+	// validator 在未开启校验时为 nil
+	validator *validator
+	bindings  apiBindings
+	// services 是各 IDL 中定义的服务名
+	services []string
+}
+
+// build 用各文件当前的版本（next 中有新版本的以新版本为准）构造路由表与字段注解，
+// 多个文件 include 同一个 struct 时 namespace 与名称都相同，以后加载的为准
+func (c *idlCatalog) build(validate bool, next map[*idlSource]*idlRevision) (*routeTable, error) {
+	t := &routeTable{bindings: make(apiBindings)}
+	if validate {
+		t.validator = &validator{structs: make(map[structKey]map[int]*fieldRules)}
+	}
+	routes := append([]Route(nil), c.static...)
+	for _, src := range c.sources {
+		rev := src.current
+		if r, ok := next[src]; ok {
+			rev = r
+		}
+		routes = append(routes, rev.routes...)
+		t.services = append(t.services, rev.services...)
+		if t.validator != nil {
+			for name, fields := range rev.rules {
+				t.validator.structs[name] = fields
+			}
+		}
+		for name, fields := range rev.bindings {
+			t.bindings[name] = fields
+		}
+	}
+	router, err := NewRouter(routes...)
+	if err != nil {
+		return nil, err
+	}
+	t.router = router
+	return t, nil
+}
+
+// reload 重新解析发生变化的文件并重建路由表，未变化时返回 nil。
+// 任一文件解析失败或路由冲突时所有文件都保留之前的版本，直到文件再次变化
+func (c *idlCatalog) reload(validate bool) (*routeTable, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cur := make(map[string]fileStamp)
+	var changed []*idlSource
+	for _, src := range c.sources {
+		stamps := src.current.snap.current()
+		for name, st := range stamps {
+			cur[name] = st
+		}
+		if !sameStamps(stamps, src.current.snap.stamps) {
+			changed = append(changed, src)
+		}
+	}
+	if len(changed) == 0 || sameStamps(cur, c.rejected) {
+		return nil, nil
+	}
+	next := make(map[*idlSource]*idlRevision, len(changed))
+	for _, src := range changed {
+		rev, err := loadRevision(src.path)
+		if err != nil {
+			c.rejected = cur
+			return nil, err
+		}
+		next[src] = rev
+	}
+	t, err := c.build(validate, next)
+	if err != nil {
+		c.rejected = cur
+		return nil, err
+	}
+	for src, rev := range next {
+		src.current = rev
+	}
+	c.rejected = nil
+	return t, nil
+}
+
+// reloadIDL 检查一次路由 IDL 与泛化服务 IDL 的变化
+func (b *bridge) reloadIDL() {
+	if t, err := b.idl.reload(b.options.Validate); err != nil {
+		klog.Errorf("KITEX: reload routes failed, keep the previous version: %v", err)
+	} else if t != nil {
+		b.table.Store(t)
+		klog.Infof("KITEX: routes reloaded from IDL")
+		b.warnUnmatched(t)
+	}
+
+	for name, target := range b.generic {
+		if ok, err := target.reload(); err != nil {
+			klog.Errorf("KITEX: reload generic service %s from %s failed, keep the previous version: %v", name, target.svc.IDL, err)
+		} else if ok {
+			klog.Infof("KITEX: generic service %s reloaded from %s", name, target.svc.IDL)
+		}
+	}
+}
+
+// watchIDL 按间隔执行 reloadIDL，直到 stop 被调用
+func (b *bridge) watchIDL(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.reloadIDL()
+		case <-b.done:
+			return
+		}
+	}
+}
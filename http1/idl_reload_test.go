@@ -0,0 +1,113 @@
+package http1
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDLReload(t *testing.T) {
+	raw, err := os.ReadFile("../idl/stability.thrift")
+	require.NoError(t, err)
+	full := string(raw)
+	// 初始版本的 STResponse 没有 name 字段，路由为 /v1/stability/st
+	idl := filepath.Join(t.TempDir(), "stability.thrift")
+	require.NoError(t, os.WriteFile(idl, []byte(strings.Replace(full, "3: optional string name", "", 1)), 0o644))
+
+	addr := startServer(t,
+		WithIDLRoutes(idl),
+		WithGenericServices(GenericService{IDL: idl, Addresses: []string{startRemote(t)}}),
+		WithIDLReload(50*time.Millisecond))
+
+	call := func(path string) (int, map[string]interface{}) {
+		status, out := postBody(t, "http://"+addr+path, "application/json", `{"Name":"bob"}`)
+		data, _ := out["data"].(map[string]interface{})
+		return status, data
+	}
+
+	status, data := call("/v1/stability/st")
+	require.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, data, "name")
+
+	// 新增字段并修改路由
+	require.NoError(t, os.WriteFile(idl, []byte(strings.Replace(full, "/v1/stability/st", "/v3/st", 1)), 0o644))
+	assert.Eventually(t, func() bool {
+		status, data := call("/v3/st")
+		return status == http.StatusOK && data["name"] == "remote:bob"
+	}, 3*time.Second, 50*time.Millisecond)
+	status, _ = call("/v1/stability/st")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// 解析失败时保留之前的版本
+	require.NoError(t, os.WriteFile(idl, []byte("service STService {"), 0o644))
+	time.Sleep(300 * time.Millisecond)
+	status, data = call("/v3/st")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "remote:bob", data["name"])
+}
+
+func TestIDLCatalog_RouteConflict(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, svc, path string) string {
+		file := filepath.Join(dir, name)
+		idl := "service " + svc + " {\n    void call() (api.post = \"" + path + "\")\n}\n"
+		require.NoError(t, os.WriteFile(file, []byte(idl), 0o644))
+		return file
+	}
+	a, b := write("a.thrift", "A", "/a"), write("b.thrift", "B", "/b")
+	c, err := newIDLCatalog(nil, []string{a, b})
+	require.NoError(t, err)
+	match := func(tbl *routeTable, path string) string {
+		if route, _ := tbl.router.Match("POST", path); route != nil {
+			return route.ServiceName
+		}
+		return ""
+	}
+
+	// b 与 a 的路由冲突：两个文件都保留之前的版本，且不会重复报告
+	write("b.thrift", "B", "/a")
+	tbl, err := c.reload(false)
+	assert.ErrorIs(t, err, ErrRouteConflict)
+	assert.Nil(t, tbl)
+	tbl, err = c.build(false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "B", match(tbl, "/b"))
+	tbl, err = c.reload(false)
+	assert.NoError(t, err)
+	assert.Nil(t, tbl)
+
+	// a 改用其他路径后冲突消失，两个文件的新版本一起生效
+	write("a.thrift", "A", "/a2")
+	tbl, err = c.reload(false)
+	require.NoError(t, err)
+	assert.Equal(t, "A", match(tbl, "/a2"))
+	assert.Equal(t, "B", match(tbl, "/a"))
+	assert.Equal(t, "", match(tbl, "/b"))
+}
+
+func TestIDLReload_SharedWatcher(t *testing.T) {
+	f := NewSvrTransHandlerFactory(WithIDLRoutes("../idl/stability.thrift"), WithIDLReload(time.Hour))
+	h1, err := f.NewTransHandler(&remote.ServerOption{})
+	require.NoError(t, err)
+	h2, err := f.NewTransHandler(&remote.ServerOption{})
+	require.NoError(t, err)
+	// 同一个工厂创建的 handler 共用路由表与 watcher
+	b := h1.(*HTTP1Handler).bridge
+	assert.Same(t, b, h2.(*HTTP1Handler).bridge)
+
+	require.NoError(t, h2.(remote.GracefulShutdown).GracefulShutdown(context.Background()))
+	require.NoError(t, h1.(remote.GracefulShutdown).GracefulShutdown(context.Background()))
+	select {
+	case <-b.done:
+	default:
+		t.Fatal("watcher not stopped")
+	}
+}
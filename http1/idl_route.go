@@ -0,0 +1,59 @@
+package http1
+
+import (
+	"strings"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// 方法级路由注解与 HTTP 方法的对应关系，写法与 Hertz 的 api.* 注解一致
+var routeAnnotations = map[string]string{
+	"api.get":     "GET",
+	"api.post":    "POST",
+	"api.put":     "PUT",
+	"api.delete":  "DELETE",
+	"api.patch":   "PATCH",
+	"api.head":    "HEAD",
+	"api.options": "OPTIONS",
+	"api.any":     AnyMethod,
+}
+
+// RoutesFromIDL 解析 Thrift IDL，把方法上的路由注解转换为 Route，例如：
+//
+//	service UserService {
+//	    User getUser(1: GetUserRequest req) (api.get = "/users/:id")
+//	}
+//
+// includeDirs 为 include 语句的搜索目录，被 include 的 IDL 中的服务同样会被解析。
+func RoutesFromIDL(path string, includeDirs ...string) ([]Route, error) {
+	ast, err := parser.ParseFile(path, includeDirs, true)
+	if err != nil {
+		return nil, err
+	}
+	return routesFromAST(ast), nil
+}
+
+func routesFromAST(ast *parser.Thrift) []Route {
+	var routes []Route
+	for t := range ast.DepthFirstSearch() {
+		for _, svc := range t.Services {
+			for _, fn := range svc.Functions {
+				for _, anno := range fn.Annotations {
+					method, ok := routeAnnotations[strings.ToLower(anno.Key)]
+					if !ok {
+						continue
+					}
+					for _, p := range anno.Values {
+						routes = append(routes, Route{
+							HTTPMethod:  method,
+							Path:        p,
+							ServiceName: svc.Name,
+							MethodName:  fn.Name,
+						})
+					}
+				}
+			}
+		}
+	}
+	return routes
+}
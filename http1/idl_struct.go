@@ -0,0 +1,123 @@
+package http1
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/cloudwego/kitex/pkg/remote"
+	"github.com/cloudwego/thriftgo/generator/golang/styles"
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// IDL struct 与 kitex_gen 生成类型的对应关系：类型名按 Kitex 默认的命名规则（golint 风格、不处理缩写、compatible_names）
+// 由 IDL 名称转换，包路径以 namespace go（未声明时为文件名）中 "." 换成 "/" 后的路径结尾，
+// 因此不同 namespace 下的同名 struct 互不覆盖
+
+// structKey 标识一个 IDL struct 对应的生成类型
+type structKey struct {
+	// pkg 是生成代码包路径的后缀，如 thrift/stability
+	pkg  string
+	name string
+}
+
+func (k structKey) String() string {
+	return k.pkg + "." + k.name
+}
+
+var kitexNaming = func() styles.Naming {
+	n := &styles.GoLint{}
+	n.UseInitialisms(false)
+	return n
+}()
+
+// newStructKey 按 Kitex 生成代码的规则计算 IDL 文件 t 中 struct st 对应的类型
+func newStructKey(t *parser.Thrift, st *parser.StructLike) structKey {
+	name, _ := kitexNaming.Identify(st.Name)
+	if strings.HasPrefix(name, "New") || strings.HasSuffix(name, "Args") || strings.HasSuffix(name, "Result") {
+		name += "_"
+	}
+	return structKey{pkg: strings.ReplaceAll(t.GetNamespaceOrReferenceName("go"), ".", "/"), name: name}
+}
+
+// matches 判断生成类型 rt 是否对应 k
+func (k structKey) matches(rt reflect.Type) bool {
+	return rt.Name() == k.name && (rt.PkgPath() == k.pkg || strings.HasSuffix(rt.PkgPath(), "/"+k.pkg))
+}
+
+// lookupStruct 查找类型 rt 对应的条目，包路径按从长到短的后缀依次匹配
+func lookupStruct[V any](m map[structKey]V, rt reflect.Type) V {
+	var zero V
+	if len(m) == 0 || rt.Name() == "" {
+		return zero
+	}
+	pkg := rt.PkgPath()
+	for {
+		if v, ok := m[structKey{pkg: pkg, name: rt.Name()}]; ok {
+			return v
+		}
+		i := strings.IndexByte(pkg, '/')
+		if i < 0 {
+			return zero
+		}
+		pkg = pkg[i+1:]
+	}
+}
+
+// unmatchedStructs 返回 keys 中与 services 的方法参数（含嵌套字段）里任何生成类型都不对应的 struct；
+// services 在 searcher 中都找不到时无从比较，返回 nil
+func unmatchedStructs(searcher remote.ServiceSearcher, services []string, keys []structKey) []structKey {
+	if searcher == nil || len(keys) == 0 {
+		return nil
+	}
+	types := make(map[reflect.Type]bool)
+	for _, name := range services {
+		svcInfo := searcher.SearchService(name, "", true)
+		if svcInfo == nil {
+			continue
+		}
+		for _, m := range svcInfo.Methods {
+			if args := m.NewArgs(); args != nil {
+				collectStructTypes(reflect.TypeOf(args), types)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	var out []structKey
+	for _, k := range keys {
+		found := false
+		for rt := range types {
+			if k.matches(rt) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+func collectStructTypes(rt reflect.Type, seen map[reflect.Type]bool) {
+	for {
+		switch rt.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			rt = rt.Elem()
+			continue
+		case reflect.Map:
+			collectStructTypes(rt.Key(), seen)
+			rt = rt.Elem()
+			continue
+		}
+		break
+	}
+	if rt.Kind() != reflect.Struct || seen[rt] {
+		return
+	}
+	seen[rt] = true
+	for i := 0; i < rt.NumField(); i++ {
+		collectStructTypes(rt.Field(i).Type, seen)
+	}
+}
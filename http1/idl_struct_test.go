@@ -0,0 +1,75 @@
+package http1
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/cloudwego/kitex/pkg/serviceinfo"
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability/stservice"
+)
+
+func TestNewStructKey(t *testing.T) {
+	ast := &parser.Thrift{Filename: "user.thrift"}
+	for idl, want := range map[string]string{
+		"get_user_req": "GetUserReq",
+		"STRequest":    "STRequest",
+		"user_id":      "UserId",
+		"GetArgs":      "GetArgs_",
+		"NewUser":      "NewUser_",
+	} {
+		assert.Equal(t, structKey{pkg: "user", name: want}, newStructKey(ast, &parser.StructLike{Name: idl}))
+	}
+
+	ast.Namespaces = []*parser.Namespace{{Language: "go", Name: "thrift.stability"}}
+	k := newStructKey(ast, &parser.StructLike{Name: "STRequest"})
+	assert.Equal(t, structKey{pkg: "thrift/stability", name: "STRequest"}, k)
+	assert.True(t, k.matches(reflect.TypeOf(stability.STRequest{})))
+	assert.False(t, k.matches(reflect.TypeOf(stability.STResponse{})))
+}
+
+func TestLookupStruct_Namespaces(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, ns string) string {
+		path := filepath.Join(dir, name)
+		idl := "namespace go " + ns + "\nstruct STRequest {\n1: optional string Name (vt.min_size = \"1\")\n}\n" +
+			"service S" + name[:1] + " { STRequest get(1: STRequest req) }\n"
+		require.NoError(t, os.WriteFile(path, []byte(idl), 0o644))
+		return path
+	}
+	c, err := newIDLCatalog(nil, []string{write("a.thrift", "thrift.stability"), write("b.thrift", "other.stability")})
+	require.NoError(t, err)
+	tbl, err := c.build(true, nil)
+	require.NoError(t, err)
+	// 同名 struct 分属不同 namespace，各自保留
+	assert.Len(t, tbl.validator.structs, 2)
+	assert.NotNil(t, lookupStruct(tbl.validator.structs, reflect.TypeOf(stability.STRequest{})))
+	assert.Nil(t, lookupStruct(tbl.validator.structs, reflect.TypeOf(stability.STResponse{})))
+
+	type STRequest struct{}
+	assert.Nil(t, lookupStruct(tbl.validator.structs, reflect.TypeOf(STRequest{})))
+}
+
+type fakeSearcher map[string]*serviceinfo.ServiceInfo
+
+func (s fakeSearcher) SearchService(svcName, _ string, _ bool) *serviceinfo.ServiceInfo {
+	return s[svcName]
+}
+
+func TestUnmatchedStructs(t *testing.T) {
+	searcher := fakeSearcher{"STService": stservice.NewServiceInfo()}
+	ok := structKey{pkg: "thrift/stability", name: "STRequest"}
+	wrongNS := structKey{pkg: "other", name: "STRequest"}
+	// 只检查方法参数中出现的类型
+	resp := structKey{pkg: "thrift/stability", name: "STResponse"}
+	assert.Equal(t, []structKey{wrongNS, resp}, unmatchedStructs(searcher, []string{"STService"}, []structKey{ok, wrongNS, resp}))
+	// 服务未注册时无从比较
+	assert.Nil(t, unmatchedStructs(searcher, []string{"Missing"}, []structKey{wrongNS}))
+	assert.Nil(t, unmatchedStructs(nil, []string{"STService"}, []structKey{wrongNS}))
+}
@@ -0,0 +1,78 @@
+package http1
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONConventions 定义 Thrift 类型与 JSON 之间的转换约定，对请求和响应对称生效。
+// 零值与 encoding/json 的行为一致。
+type JSONConventions struct {
+	// EnumAsName 为 true 时枚举输出为名称（如 "FIRST"）；请求中名称与数值都可以接受，
+	// 按名称解析需要先通过 RegisterEnum 注册
+	EnumAsName bool
+	// Int64AsString 为 true 时 i64 输出为字符串，避免 JavaScript 丢失精度；请求中字符串与数值都可以接受
+	Int64AsString bool
+	// BinaryAsHex 为 true 时 binary 使用 hex 编码，否则使用 base64
+	BinaryAsHex bool
+	// UniqueSets 为 true 时校验 set 元素唯一：请求中出现重复元素返回 400，响应中自动去重
+	UniqueSets bool
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// isEnumType 判断是否为 Thrift 生成的枚举类型：命名的 int64 且实现了 String()
+func isEnumType(t reflect.Type) bool {
+	return t.Kind() == reflect.Int64 && t.PkgPath() != "" && t.Implements(stringerType)
+}
+
+// enumName 返回枚举值的名称，未定义的值返回 false
+func enumName(v reflect.Value) (string, bool) {
+	name := v.Interface().(fmt.Stringer).String()
+	return name, name != "<UNSET>"
+}
+
+// isSetField 根据 frugal tag 判断字段在 IDL 中是否声明为 set
+func isSetField(sf reflect.StructField) bool {
+	parts := strings.SplitN(sf.Tag.Get("frugal"), ",", 3)
+	return len(parts) == 3 && strings.HasPrefix(parts[2], "set<")
+}
+
+// dedupeSet 去掉 set 中的重复元素，并返回第一个重复元素的下标（没有重复时为 -1）
+func dedupeSet(v reflect.Value) (reflect.Value, int) {
+	if v.Kind() != reflect.Slice || v.Len() < 2 {
+		return v, -1
+	}
+	var (
+		out      = reflect.MakeSlice(v.Type(), 0, v.Len())
+		firstDup = -1
+		et       = v.Type().Elem()
+		// 指针可比较但比较的是地址，需要按内容判断
+		byKey = et.Comparable() && et.Kind() != reflect.Ptr && et.Kind() != reflect.Interface
+		seen  = make(map[interface{}]struct{}, v.Len())
+	)
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		dup := false
+		if byKey {
+			_, dup = seen[item.Interface()]
+			seen[item.Interface()] = struct{}{}
+		} else {
+			for j := 0; j < out.Len() && !dup; j++ {
+				dup = reflect.DeepEqual(out.Index(j).Interface(), item.Interface())
+			}
+		}
+		if dup {
+			if firstDup < 0 {
+				firstDup = i
+			}
+			continue
+		}
+		out = reflect.Append(out, item)
+	}
+	if firstDup < 0 {
+		return v, -1
+	}
+	return out, firstDup
+}
@@ -0,0 +1,300 @@
+package http1
+
+import (
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonStreamEncoder 按 encoding/json 的规则把值增量编码到 io.Writer。
+//
+// 与 json.Marshal 先在内存中生成完整结果不同，这里逐个字段、逐个元素地写出，
+// 配合 responseWriter 可以把大 map / list 直接流式写到连接上。
+// 叶子节点（字符串、数字、实现了 json.Marshaler 的类型等）仍交给 encoding/json 处理，
+// 枚举、i64、binary 与 set 按 JSONConventions 输出。
+type jsonStreamEncoder struct {
+	w       io.Writer
+	conv    JSONConventions
+	scratch []byte
+}
+
+func newJSONStreamEncoder(w io.Writer, conv JSONConventions) *jsonStreamEncoder {
+	return &jsonStreamEncoder{w: w, conv: conv, scratch: make([]byte, 0, 64)}
+}
+
+// Encode 编码 v 并写出
+func (e *jsonStreamEncoder) Encode(v interface{}) error {
+	return e.encode(reflect.ValueOf(v))
+}
+
+func (e *jsonStreamEncoder) writeString(s string) error {
+	_, err := io.WriteString(e.w, s)
+	return err
+}
+
+func (e *jsonStreamEncoder) writeLeaf(v reflect.Value) error {
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonStreamEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		return e.writeString("null")
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+			return e.writeString("null")
+		}
+		return e.writeLeaf(v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return e.writeString("null")
+		}
+		return e.encode(v.Elem())
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	case reflect.Map:
+		return e.encodeMap(v)
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.writeString("null")
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if e.conv.BinaryAsHex {
+				return e.writeLeaf(reflect.ValueOf(hex.EncodeToString(v.Bytes())))
+			}
+			// []byte 按 encoding/json 的约定输出 base64
+			return e.writeLeaf(v)
+		}
+		return e.encodeList(v)
+	case reflect.Array:
+		return e.encodeList(v)
+	case reflect.Bool:
+		e.scratch = strconv.AppendBool(e.scratch[:0], v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if isEnumType(v.Type()) {
+			if name, ok := enumName(v); ok && e.conv.EnumAsName {
+				return e.writeLeaf(reflect.ValueOf(name))
+			}
+		} else if v.Kind() == reflect.Int64 && e.conv.Int64AsString {
+			e.scratch = append(strconv.AppendInt(append(e.scratch[:0], '"'), v.Int(), 10), '"')
+			break
+		}
+		e.scratch = strconv.AppendInt(e.scratch[:0], v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.scratch = strconv.AppendUint(e.scratch[:0], v.Uint(), 10)
+	default:
+		// 字符串转义、浮点数格式等细节与 encoding/json 保持一致
+		return e.writeLeaf(v)
+	}
+	_, err := e.w.Write(e.scratch)
+	return err
+}
+
+func (e *jsonStreamEncoder) encodeStruct(v reflect.Value) error {
+	if err := e.writeString("{"); err != nil {
+		return err
+	}
+	first := true
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, omitEmpty, ok := jsonFieldName(sf)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if e.conv.UniqueSets && isSetField(sf) {
+			fv, _ = dedupeSet(fv)
+		}
+		if !first {
+			if err := e.writeString(","); err != nil {
+				return err
+			}
+		}
+		first = false
+		if err := e.writeLeaf(reflect.ValueOf(name)); err != nil {
+			return err
+		}
+		if err := e.writeString(":"); err != nil {
+			return err
+		}
+		if err := e.encode(fv); err != nil {
+			return err
+		}
+	}
+	return e.writeString("}")
+}
+
+func (e *jsonStreamEncoder) encodeMap(v reflect.Value) error {
+	if v.IsNil() {
+		return e.writeString("null")
+	}
+	// 与 encoding/json 一样按 key 排序，只有 key 需要额外保留在内存中
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := e.mapKeyString(iter.Key())
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+		values[k] = iter.Value()
+	}
+	sort.Strings(keys)
+	if err := e.writeString("{"); err != nil {
+		return err
+	}
+	for i, k := range keys {
+		if i > 0 {
+			if err := e.writeString(","); err != nil {
+				return err
+			}
+		}
+		if err := e.writeLeaf(reflect.ValueOf(k)); err != nil {
+			return err
+		}
+		if err := e.writeString(":"); err != nil {
+			return err
+		}
+		if err := e.encode(values[k]); err != nil {
+			return err
+		}
+	}
+	return e.writeString("}")
+}
+
+func (e *jsonStreamEncoder) encodeList(v reflect.Value) error {
+	if err := e.writeString("["); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			if err := e.writeString(","); err != nil {
+				return err
+			}
+		}
+		if err := e.encode(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return e.writeString("]")
+}
+
+// jsonFieldName 解析字段名，返回字段名、是否 omitempty 以及是否需要输出。
+// Thrift 生成的字段优先使用 thrift tag 中的 IDL 名称，其余字段按 json tag 处理
+func jsonFieldName(sf reflect.StructField) (name string, omitEmpty, ok bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	if idlName, optional, isThrift := thriftFieldName(sf); isThrift {
+		_, omitEmpty = parseJSONTag(sf.Name, tag)
+		return idlName, omitEmpty || optional, true
+	}
+	name, omitEmpty = parseJSONTag(sf.Name, tag)
+	return name, omitEmpty, true
+}
+
+// thriftFieldName 解析 `thrift:"name,id,requiredness"` tag，返回 IDL 字段名及是否为 optional
+func thriftFieldName(sf reflect.StructField) (name string, optional, ok bool) {
+	tag, ok := sf.Tag.Lookup("thrift")
+	if !ok {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	if parts[0] == "" {
+		return "", false, false
+	}
+	return parts[0], len(parts) > 2 && parts[2] == "optional", true
+}
+
+// thriftFieldID 返回 thrift tag 中的字段 ID
+func thriftFieldID(sf reflect.StructField) (int, bool) {
+	parts := strings.Split(sf.Tag.Get("thrift"), ",")
+	if len(parts) < 2 {
+		return 0, false
+	}
+	id, err := strconv.Atoi(parts[1])
+	return id, err == nil
+}
+
+func parseJSONTag(fieldName, tag string) (name string, omitEmpty bool) {
+	name = fieldName
+	if tag != "" {
+		parts := strings.Split(tag, ",")
+		if parts[0] != "" {
+			name = parts[0]
+		}
+		for _, opt := range parts[1:] {
+			if opt == "omitempty" {
+				omitEmpty = true
+			}
+		}
+	}
+	return name, omitEmpty
+}
+
+func (e *jsonStreamEncoder) mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if e.conv.EnumAsName && isEnumType(k.Type()) {
+		if name, ok := enumName(k); ok {
+			return name, nil
+		}
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("json: unsupported map key type %s", k.Type())
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
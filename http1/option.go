@@ -0,0 +1,232 @@
+package http1
+
+import (
+	"strings"
+	"time"
+)
+
+// 默认请求体大小上限：10MB
+const defaultMaxBodySize = 10 * 1024 * 1024
+
+// 默认请求行与 Header 的总大小上限：1MB
+const defaultMaxHeaderBytes = 1 << 20
+
+// 默认 multipart 单个 part 大小上限：4MB，且不超过 MaxBodySize
+const defaultMaxFormPartSize = 4 * 1024 * 1024
+
+// Options 是 HTTP 桥接的可配置项，通过 Option 在创建工厂时设置
+type Options struct {
+	// MaxBodySize 限制单个请求体的最大字节数（chunked 编码按解码后的总长度计算）
+	MaxBodySize int
+	// MaxHeaderBytes 限制 HTTP/1.x 请求行与 Header 的总字节数，chunked trailer 单独按同样的上限计算
+	MaxHeaderBytes int
+	// MaxFormPartSize 限制 multipart/form-data 中单个 part（含文件）的最大字节数，不能超过 MaxBodySize；
+	// 为 0 时使用默认值
+	MaxFormPartSize int
+	// ChunkedThreshold 响应体超过该字节数时改用 Transfer-Encoding: chunked 流式输出
+	ChunkedThreshold int
+	// Routes 是自定义的 REST 路由，未命中时回退到 /api/{Service}/{Method} 约定
+	Routes []Route
+	// IDLRoutes 是读取 api.* 路由注解与 vt.* 校验注解的 IDL 文件，开启热加载后随文件变化重新解析
+	IDLRoutes []string
+	// Validate 为 true 时按 IDLRoutes 中的 vt.* 注解校验请求参数
+	Validate bool
+	// IDLReloadInterval 大于 0 时按该间隔检查 IDLRoutes 与泛化服务的 IDL 文件是否变化
+	IDLReloadInterval time.Duration
+	// MethodVerbs 限制 Thrift 方法可接受的 HTTP 方法，key 为 "Service.Method"
+	MethodVerbs map[string][]string
+	// ErrorMapper 把错误转换为 HTTP 状态码与响应体
+	ErrorMapper ErrorMapper
+	// ProblemDetails 为 true 时错误响应使用 RFC 7807 application/problem+json 格式
+	ProblemDetails bool
+	// ResponseEncoder 是全局的响应 Body 结构，ResponseEncoders 按 "Service.Method" 为单个方法覆盖
+	ResponseEncoder  ResponseEncoder
+	ResponseEncoders map[string]ResponseEncoder
+	// JSON 定义枚举、i64、binary 与 set 在请求和响应中的 JSON 表示
+	JSON JSONConventions
+	// WebSocketPath 非空时该路径接受 WebSocket 升级，之后每条消息作为一次调用处理
+	WebSocketPath string
+	// WebSocketPingInterval 是服务端发送 ping 的间隔
+	WebSocketPingInterval time.Duration
+
+	// generic 是 WithGenericServices 配置的泛化转发服务
+	generic []*genericGroup
+}
+
+// Option 用于修改 Options
+type Option func(o *Options)
+
+// WithMaxHeaderBytes 设置 HTTP/1.x 请求行与 Header 的总大小上限，n <= 0 时使用默认值；
+// 超过上限或 Header 数量过多时返回 431 并关闭连接
+func WithMaxHeaderBytes(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.MaxHeaderBytes = n
+		}
+	}
+}
+
+// WithMaxBodySize 设置请求体大小上限，n <= 0 时使用默认值
+func WithMaxBodySize(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.MaxBodySize = n
+		}
+	}
+}
+
+// WithMaxFormPartSize 设置 multipart/form-data 中单个 part 的大小上限，n <= 0 时使用默认值。
+// multipart Body 从连接上边读边解析，只有解析出的字段值保留在内存中；整个 Body 仍受 WithMaxBodySize 限制，
+// 因此 n 大于 MaxBodySize 时创建处理器失败
+func WithMaxFormPartSize(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.MaxFormPartSize = n
+		}
+	}
+}
+
+// WithChunkedThreshold 设置切换为 chunked 流式响应的阈值，n <= 0 时使用默认值
+func WithChunkedThreshold(n int) Option {
+	return func(o *Options) {
+		if n > 0 {
+			o.ChunkedThreshold = n
+		}
+	}
+}
+
+// WithRoutes 追加 REST 路由，可以来自配置文件或 RoutesFromIDL 解析的 IDL 注解
+func WithRoutes(routes ...Route) Option {
+	return func(o *Options) {
+		o.Routes = append(o.Routes, routes...)
+	}
+}
+
+// WithIDLRoutes 从 IDL 文件的 api.* 注解加载路由，与 RoutesFromIDL 相同，但可以随 WithIDLReload 热加载
+func WithIDLRoutes(paths ...string) Option {
+	return func(o *Options) {
+		o.IDLRoutes = append(o.IDLRoutes, paths...)
+	}
+}
+
+// WithValidation 在调用 handler 之前按 WithIDLRoutes 加载的 IDL 中的 vt.* 注解校验请求参数，
+// 校验失败返回 400，响应体中列出每个字段的路径与原因；泛化转发的请求不经过校验
+func WithValidation() Option {
+	return func(o *Options) {
+		o.Validate = true
+	}
+}
+
+// WithIDLReload 按 interval 检查路由与泛化服务的 IDL 文件，变化后重新解析并原子替换路由表和 IDL 描述，
+// 进行中的请求与已建立的连接不受影响；解析失败时记录错误并保留之前的版本
+func WithIDLReload(interval time.Duration) Option {
+	return func(o *Options) {
+		o.IDLReloadInterval = interval
+	}
+}
+
+// WithMethodVerbs 限制某个 Thrift 方法只接受指定的 HTTP 方法，其它方法返回 405 并带上 Allow 头，例如：
+//
+//	http1.WithMethodVerbs("STService", "testSTReq", "GET", "POST")
+func WithMethodVerbs(service, method string, verbs ...string) Option {
+	return func(o *Options) {
+		if o.MethodVerbs == nil {
+			o.MethodVerbs = make(map[string][]string)
+		}
+		key := service + "." + method
+		for _, v := range verbs {
+			o.MethodVerbs[key] = append(o.MethodVerbs[key], strings.ToUpper(v))
+		}
+	}
+}
+
+// WithErrorMapper 替换默认的错误映射规则
+func WithErrorMapper(m ErrorMapper) Option {
+	return func(o *Options) {
+		if m != nil {
+			o.ErrorMapper = m
+		}
+	}
+}
+
+// WithProblemDetails 让错误响应使用 RFC 7807 application/problem+json 格式
+func WithProblemDetails() Option {
+	return func(o *Options) {
+		o.ProblemDetails = true
+	}
+}
+
+// WithResponseEncoder 全局替换响应 Body 结构，如 RawResponseEncoder
+func WithResponseEncoder(enc ResponseEncoder) Option {
+	return func(o *Options) {
+		if enc != nil {
+			o.ResponseEncoder = enc
+		}
+	}
+}
+
+// WithMethodResponseEncoder 为单个 Thrift 方法指定响应 Body 结构，enc 为 nil 时该方法恢复使用全局的 ResponseEncoder
+func WithMethodResponseEncoder(service, method string, enc ResponseEncoder) Option {
+	return func(o *Options) {
+		if enc == nil {
+			delete(o.ResponseEncoders, service+"."+method)
+			return
+		}
+		if o.ResponseEncoders == nil {
+			o.ResponseEncoders = make(map[string]ResponseEncoder)
+		}
+		o.ResponseEncoders[service+"."+method] = enc
+	}
+}
+
+// WithJSONConventions 设置 Thrift 类型的 JSON 表示约定，例如枚举输出名称、i64 输出字符串：
+//
+//	http1.WithJSONConventions(http1.JSONConventions{EnumAsName: true, Int64AsString: true})
+func WithJSONConventions(c JSONConventions) Option {
+	return func(o *Options) {
+		o.JSON = c
+	}
+}
+
+// WithWebSocket 在 path 上接受 WebSocket 升级，之后每条文本/二进制消息是一个
+// {id, service, method, args} 信封，按 id 回复 {id, status, body}。
+// 每条消息视为一次 POST 调用，同样受 WithMethodVerbs 限制
+func WithWebSocket(path string) Option {
+	return func(o *Options) {
+		o.WebSocketPath = path
+	}
+}
+
+// WithWebSocketPingInterval 设置 WebSocket 连接的 ping 间隔，d <= 0 时使用默认值
+func WithWebSocketPingInterval(d time.Duration) Option {
+	return func(o *Options) {
+		if d > 0 {
+			o.WebSocketPingInterval = d
+		}
+	}
+}
+
+// WithGenericServices 按 IDL 把对应服务的请求泛化转发给下游，JSON 与 Thrift binary 之间的转换由 IDL 描述完成，
+// 不依赖 kitex_gen 生成的类型；路由方式与本地服务相同，同名服务优先泛化转发
+func WithGenericServices(svcs ...GenericService) Option {
+	g := &genericGroup{services: svcs}
+	return func(o *Options) {
+		o.generic = append(o.generic, g)
+	}
+}
+
+func newOptions(opts []Option) *Options {
+	o := &Options{
+		MaxBodySize:      defaultMaxBodySize,
+		MaxHeaderBytes:   defaultMaxHeaderBytes,
+		ChunkedThreshold: defaultChunkedThreshold,
+		ErrorMapper:      DefaultErrorMapper,
+		ResponseEncoder:  DefaultResponseEncoder,
+
+		WebSocketPingInterval: defaultWebSocketPingInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -3,6 +3,10 @@ package http1
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -14,12 +18,56 @@ var (
 	ErrInvalidRequestLine = errors.New("invalid HTTP request line")
 	ErrInvalidPathFormat  = errors.New("invalid path format, expected /api/{Service}/{Method}")
 	ErrInvalidContentLen  = errors.New("invalid Content-Length header")
+	ErrUnsupportedProto   = errors.New("unsupported HTTP protocol version")
+	ErrInvalidPathEscape  = errors.New("invalid escape in request path")
+	ErrHeaderTooLarge     = errors.New("request header too large")
 )
 
-// 读取一行（以 \n 结束）并去掉末尾的 \r\n
-func readLine(reader netpoll.Reader) ([]byte, error) {
+// 单个请求（或 chunked trailer）最多包含的 Header 行数
+const maxHeaderCount = 256
+
+// readLimits 是读取单个 HTTP/1.x 请求时的大小限制
+type readLimits struct {
+	headerBytes, body, formPart int
+}
+
+// MethodNotAllowedError 表示请求路径存在，但不接受当前的 HTTP 方法
+type MethodNotAllowedError struct {
+	Method string
+	Allow  []string
+}
+
+func (e *MethodNotAllowedError) Error() string {
+	return fmt.Sprintf("method %s not allowed, allow: %s", e.Method, strings.Join(e.Allow, ", "))
+}
+
+// request 是一次完整解析后的 HTTP/1.x 请求，连接上的每个请求各自持有一份
+type request struct {
+	method      string
+	path        string // 不含 query 部分
+	rawQuery    string
+	proto       string // 如 HTTP/1.1
+	serviceName string
+	methodName  string
+	// pathParams 是路由模板捕获的路径变量，如 /users/:id 中的 id
+	pathParams map[string]string
+	headers    map[string]string
+	body       []byte
+	// form 是读取请求时从连接上流式解析的 multipart/form-data 字段，此时 body 为空；
+	// formErr 是解析失败的原因，在参数绑定时返回，使请求仍能得到应答
+	form    url.Values
+	formErr error
+	// keepAlive 表示响应写出后连接是否还能继续承载下一个请求
+	keepAlive bool
+}
+
+// 读取一行（以 \n 结束）并去掉末尾的 \r\n，行长度（含换行符）超过 max 时返回 ErrHeaderTooLarge
+func readLine(reader netpoll.Reader, max int) ([]byte, error) {
 	var line []byte
 	for {
+		if len(line) >= max {
+			return nil, ErrHeaderTooLarge
+		}
 		b, err := reader.ReadByte()
 		if err != nil {
 			return nil, err
@@ -37,61 +85,263 @@ func readLine(reader netpoll.Reader) ([]byte, error) {
 
 // parseRequestLine 解析第一行请求行：如 POST /api/Service/Method HTTP/1.1
 func parseRequestLine(reader netpoll.Reader) (method, serviceName, methodName string, err error) {
-	line, err := readLine(reader)
+	line, err := readLine(reader, defaultMaxHeaderBytes)
 	if err != nil {
 		return "", "", "", err
 	}
 
+	method, target, _, err := splitRequestLine(line)
+	if err != nil {
+		return "", "", "", err
+	}
+	path, _ := splitTarget(target)
+	serviceName, methodName, err = resolveAPIPath(path)
+	if err != nil {
+		return "", "", "", err
+	}
+	return method, serviceName, methodName, nil
+}
+
+// splitRequestLine 将请求行拆分为 method、request-target 和协议版本
+func splitRequestLine(line []byte) (method, target, proto string, err error) {
 	parts := bytes.SplitN(line, []byte(" "), 3)
 	if len(parts) < 3 {
 		return "", "", "", ErrInvalidRequestLine
 	}
+	return string(parts[0]), string(parts[1]), string(bytes.TrimSpace(parts[2])), nil
+}
 
-	method = string(parts[0])
-	path := string(parts[1])
-	// version := string(parts[2]) // 可选保留
+// splitTarget 将 request-target 拆分为 path 与 query
+func splitTarget(target string) (path, rawQuery string) {
+	if i := strings.IndexByte(target, '?'); i >= 0 {
+		return target[:i], target[i+1:]
+	}
+	return target, ""
+}
 
+// resolveAPIPath 从 /api/{Service}/{Method} 中取出服务名和方法名
+func resolveAPIPath(path string) (serviceName, methodName string, err error) {
 	pathParts := strings.Split(path, "/")
 	if len(pathParts) < 4 || pathParts[1] != "api" {
-		return "", "", "", ErrInvalidPathFormat
+		return "", "", ErrInvalidPathFormat
 	}
-
-	serviceName = pathParts[2]
-	methodName = pathParts[3]
-	return
+	return pathParts[2], pathParts[3], nil
 }
 
-// parseHeaders 解析 Header 字段，直到遇到空行 \r\n\r\n，返回 Header 映射和 Content-Length 值
-func parseHeaders(reader netpoll.Reader) (map[string]string, int, error) {
+// parseHeaders 解析 Header 字段，直到遇到空行 \r\n\r\n，返回 Header 映射和 Content-Length 值。
+// Header 名称统一转换为规范格式（如 content-length → Content-Length），同名 Header 取最后一个值；
+// 多个取值不同的 Content-Length 无法确定报文边界，按请求走私处理返回 ErrInvalidContentLen；
+// 各行总长度超过 maxBytes 或行数超过 maxHeaderCount 时返回 ErrHeaderTooLarge
+func parseHeaders(reader netpoll.Reader, maxBytes int) (map[string]string, int, error) {
 	headers := make(map[string]string)
 	var contentLength int
 
-	for {
-		line, err := readLine(reader)
+	for n := 0; ; n++ {
+		line, err := readLine(reader, maxBytes)
 		if err != nil {
 			return nil, 0, err
 		}
 		if len(line) == 0 {
 			break // 空行，header 结束
 		}
+		if n >= maxHeaderCount {
+			return nil, 0, ErrHeaderTooLarge
+		}
+		maxBytes -= len(line) + 2
 
 		parts := bytes.SplitN(line, []byte(":"), 2)
 		if len(parts) != 2 {
 			continue
 		}
 
-		key := strings.TrimSpace(string(parts[0]))
+		key := textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(string(parts[0])))
 		val := strings.TrimSpace(string(parts[1]))
-		headers[key] = val
-
-		if strings.EqualFold(key, "Content-Length") {
+		if key == "Content-Length" {
+			if prev, seen := headers[key]; seen && prev != val {
+				return nil, 0, ErrInvalidContentLen
+			}
 			cl, err := strconv.Atoi(val)
 			if err != nil {
 				return nil, 0, ErrInvalidContentLen
 			}
 			contentLength = cl
 		}
+		headers[key] = val
 	}
 
 	return headers, contentLength, nil
 }
+
+// readRequest 从连接的 reader 中读取一个完整的请求（请求行、Header 和 Body），
+// 服务名与方法名由 handler 在路由匹配后填充。
+// reader 在同一连接的多个请求之间复用，因此流水线中后续请求的字节不会丢失。
+// Body 支持 Content-Length 与 Transfer-Encoding: chunked 两种定界方式，长度受 maxBodySize 限制；
+// multipart/form-data 的 Body 不整体读入内存，而是边读边按 part 解析，单个 part 受 limits.formPart 限制；
+// 请求行与 Header 的总长度受 limits.headerBytes 限制，避免长连接上的单个请求无限占用内存。
+func readRequest(reader netpoll.Reader, limits readLimits) (*request, error) {
+	maxBodySize, maxFormPartSize := limits.body, limits.formPart
+	line, err := readLine(reader, limits.headerBytes)
+	if err != nil {
+		return nil, err
+	}
+	method, target, proto, err := splitRequestLine(line)
+	if err != nil {
+		return nil, err
+	}
+	if proto != "HTTP/1.1" && proto != "HTTP/1.0" {
+		return nil, ErrUnsupportedProto
+	}
+	path, rawQuery := splitTarget(target)
+
+	headers, contentLength, err := parseHeaders(reader, limits.headerBytes-len(line)-2)
+	if err != nil {
+		return nil, err
+	}
+	req := &request{
+		method:    method,
+		path:      path,
+		rawQuery:  rawQuery,
+		proto:     proto,
+		headers:   headers,
+		keepAlive: shouldKeepAlive(proto, headers),
+	}
+
+	chunked, err := isChunked(headerValue(headers, "Transfer-Encoding"))
+	if err != nil {
+		return nil, err
+	}
+	boundary, multipart := multipartBoundary(headerValue(headers, "Content-Type"))
+	if chunked {
+		// 同时携带 Content-Length 时以 Transfer-Encoding 为准，并在响应后关闭连接，防止请求走私
+		if headerValue(headers, "Content-Length") != "" {
+			req.keepAlive = false
+		}
+		if multipart {
+			cr := &chunkedReader{r: reader, max: maxBodySize, maxHeader: limits.headerBytes}
+			if err := req.readForm(cr, &cr.err, boundary, maxFormPartSize); err != nil {
+				return nil, err
+			}
+			mergeTrailers(headers, cr.trailers)
+			return req, nil
+		}
+		body, trailers, err := readChunkedBody(reader, maxBodySize, limits.headerBytes)
+		if err != nil {
+			return nil, err
+		}
+		req.body = body
+		mergeTrailers(headers, trailers)
+		return req, nil
+	}
+
+	if contentLength < 0 {
+		return nil, ErrInvalidContentLen
+	}
+	if contentLength > maxBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	if contentLength > 0 && multipart {
+		lr := &lengthReader{r: reader, left: contentLength}
+		if err := req.readForm(lr, &lr.err, boundary, maxFormPartSize); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+	if contentLength > 0 {
+		// ReadBinary 会拷贝数据，body 的生命周期因此与 reader.Release 无关
+		if req.body, err = reader.ReadBinary(contentLength); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// readForm 从 body 中流式解析 multipart 表单。
+// bodyErr 指向 body 的读取错误：连接读取失败时返回该错误；
+// 表单格式错误时丢弃 Body 剩余部分，连接可以继续使用；Body 或单个 part 超过上限时不再读取剩余部分，应答后关闭连接
+func (req *request) readForm(body io.Reader, bodyErr *error, boundary string, maxPartSize int) error {
+	req.form, req.formErr = readMultipart(body, boundary, maxPartSize)
+	if !errors.Is(req.formErr, ErrBodyTooLarge) {
+		_, _ = io.Copy(io.Discard, body)
+	}
+	switch err := *bodyErr; {
+	case errors.Is(err, ErrBodyTooLarge):
+		req.form, req.formErr = nil, err
+	case err != nil && err != io.EOF:
+		return err
+	}
+	if errors.Is(req.formErr, ErrBodyTooLarge) {
+		req.keepAlive = false
+	}
+	return nil
+}
+
+// mergeTrailers 把 chunked trailer 合并到 Header 中，但不覆盖已有字段
+func mergeTrailers(headers, trailers map[string]string) {
+	for k, v := range trailers {
+		if headerValue(headers, k) == "" {
+			headers[k] = v
+		}
+	}
+}
+
+// lengthReader 读取 Content-Length 定界的 Body，已读取的数据立即从 reader 中释放
+type lengthReader struct {
+	r    netpoll.Reader
+	left int
+	// err 是第一个读取错误，之后的 Read 都返回它
+	err error
+}
+
+func (l *lengthReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.left == 0 {
+		l.err = io.EOF
+		return 0, io.EOF
+	}
+	n := min(len(p), l.left)
+	if avail := l.r.Len(); avail > 0 && avail < n {
+		n = avail
+	}
+	b, err := l.r.Next(n)
+	if err != nil {
+		l.err = err
+		return 0, err
+	}
+	copy(p, b)
+	l.left -= n
+	return n, l.r.Release()
+}
+
+// shouldKeepAlive 按 HTTP/1.0 与 HTTP/1.1 的默认语义及 Connection 头判断是否保持连接
+func shouldKeepAlive(proto string, headers map[string]string) bool {
+	conn := headerValue(headers, "Connection")
+	if proto == "HTTP/1.0" {
+		return hasToken(conn, "keep-alive")
+	}
+	return !hasToken(conn, "close")
+}
+
+// headerValue 忽略大小写查找 Header 值
+func headerValue(headers map[string]string, key string) string {
+	if v, ok := headers[key]; ok {
+		return v
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// hasToken 判断以逗号分隔的 Header 值中是否包含指定 token（忽略大小写）
+func hasToken(value, token string) bool {
+	for _, t := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), token) {
+			return true
+		}
+	}
+	return false
+}
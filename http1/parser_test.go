@@ -2,12 +2,17 @@ package http1
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/cloudwego/netpoll"
 	"github.com/stretchr/testify/assert"
 )
 
+var defaultReadLimits = readLimits{
+	headerBytes: defaultMaxHeaderBytes, body: defaultMaxBodySize, formPart: defaultMaxFormPartSize,
+}
+
 func TestParseRequestLine(t *testing.T) {
 	// 模拟 HTTP 请求行
 	input := []byte("POST /api/UserService/GetUser HTTP/1.1\r\n")
@@ -38,7 +43,7 @@ func TestReadLine(t *testing.T) {
 	input := []byte("GET /test HTTP/1.1\r\n")
 	r := netpoll.NewReader(bytes.NewReader(input))
 
-	line, err := readLine(r)
+	line, err := readLine(r, defaultMaxHeaderBytes)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "GET /test HTTP/1.1", string(line))
@@ -52,7 +57,7 @@ func TestParseHeaders(t *testing.T) {
 			"\r\n")
 	reader := netpoll.NewReader(bytes.NewReader(input))
 
-	headers, contentLength, err := parseHeaders(reader)
+	headers, contentLength, err := parseHeaders(reader, defaultMaxHeaderBytes)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 3, len(headers))
@@ -67,8 +72,143 @@ func TestParseHeaders_InvalidContentLength(t *testing.T) {
 			"\r\n")
 	reader := netpoll.NewReader(bytes.NewReader(input))
 
-	_, _, err := parseHeaders(reader)
+	_, _, err := parseHeaders(reader, defaultMaxHeaderBytes)
 
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidContentLen, err)
 }
+
+func TestParseHeaders_DuplicateContentLength(t *testing.T) {
+	// 名称大小写不同的 Header 视为同一个，取值不同的 Content-Length 被拒绝
+	input := []byte(
+		"Content-Length: 5\r\n" +
+			"content-length: 50\r\n" +
+			"\r\n")
+	_, _, err := parseHeaders(netpoll.NewReader(bytes.NewReader(input)), defaultMaxHeaderBytes)
+	assert.Equal(t, ErrInvalidContentLen, err)
+
+	input = []byte(
+		"content-length: 5\r\n" +
+			"Content-Length: 5\r\n" +
+			"accept: a\r\n" +
+			"Accept: b\r\n" +
+			"\r\n")
+	headers, contentLength, err := parseHeaders(netpoll.NewReader(bytes.NewReader(input)), defaultMaxHeaderBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, contentLength)
+	assert.Equal(t, map[string]string{"Content-Length": "5", "Accept": "b"}, headers)
+}
+
+func TestReadRequest_Pipelined(t *testing.T) {
+	input := []byte(
+		"POST /api/STService/testSTReq HTTP/1.1\r\n" +
+			"Content-Length: 2\r\n" +
+			"\r\n" +
+			"{}" +
+			"POST /api/STService/testSTReq?framework=kitex HTTP/1.1\r\n" +
+			"Connection: close\r\n" +
+			"Content-Length: 4\r\n" +
+			"\r\n" +
+			"null")
+	r := netpoll.NewReader(bytes.NewReader(input))
+
+	first, err := readRequest(r, defaultReadLimits)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(first.body))
+	assert.True(t, first.keepAlive)
+
+	second, err := readRequest(r, defaultReadLimits)
+	assert.NoError(t, err)
+	assert.Equal(t, "/api/STService/testSTReq", second.path)
+	assert.Equal(t, "framework=kitex", second.rawQuery)
+	assert.Equal(t, "null", string(second.body))
+	assert.False(t, second.keepAlive)
+}
+
+func TestShouldKeepAlive(t *testing.T) {
+	assert.True(t, shouldKeepAlive("HTTP/1.1", map[string]string{}))
+	assert.False(t, shouldKeepAlive("HTTP/1.1", map[string]string{"connection": "Close"}))
+	assert.False(t, shouldKeepAlive("HTTP/1.0", map[string]string{}))
+	assert.True(t, shouldKeepAlive("HTTP/1.0", map[string]string{"Connection": "keep-alive"}))
+}
+
+func TestReadRequest_Chunked(t *testing.T) {
+	input := []byte(
+		"POST /api/STService/testSTReq HTTP/1.1\r\n" +
+			"Transfer-Encoding: chunked\r\n" +
+			"\r\n" +
+			"7;name=value\r\n" +
+			"{\"req\":\r\n" +
+			"3\r\n" +
+			"{}}\r\n" +
+			"0\r\n" +
+			"X-Checksum: abc\r\n" +
+			"\r\n")
+	r := netpoll.NewReader(bytes.NewReader(input))
+
+	req, err := readRequest(r, defaultReadLimits)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"req":{}}`, string(req.body))
+	assert.Equal(t, "abc", req.headers["X-Checksum"])
+	assert.True(t, req.keepAlive)
+}
+
+func TestReadRequest_ChunkedTooLarge(t *testing.T) {
+	input := []byte(
+		"POST /api/STService/testSTReq HTTP/1.1\r\n" +
+			"Transfer-Encoding: chunked\r\n" +
+			"\r\n" +
+			"a\r\n" +
+			"0123456789\r\n" +
+			"0\r\n" +
+			"\r\n")
+	r := netpoll.NewReader(bytes.NewReader(input))
+
+	_, err := readRequest(r, readLimits{headerBytes: defaultMaxHeaderBytes, body: 8, formPart: 8})
+	assert.Equal(t, ErrBodyTooLarge, err)
+}
+
+func TestReadRequest_UnsupportedTransferEncoding(t *testing.T) {
+	input := []byte(
+		"POST /api/STService/testSTReq HTTP/1.1\r\n" +
+			"Transfer-Encoding: gzip, chunked\r\n" +
+			"\r\n")
+	r := netpoll.NewReader(bytes.NewReader(input))
+
+	_, err := readRequest(r, defaultReadLimits)
+	assert.Equal(t, ErrUnsupportedTransferCoding, err)
+}
+
+func TestReadRequest_HeaderTooLarge(t *testing.T) {
+	limits := readLimits{headerBytes: 128, body: defaultMaxBodySize, formPart: defaultMaxFormPartSize}
+	read := func(input string) error {
+		_, err := readRequest(netpoll.NewReader(strings.NewReader(input)), limits)
+		return err
+	}
+
+	t.Run("request line", func(t *testing.T) {
+		err := read("GET /api/STService/" + strings.Repeat("a", 200) + " HTTP/1.1\r\n\r\n")
+		assert.Equal(t, ErrHeaderTooLarge, err)
+	})
+	t.Run("header bytes", func(t *testing.T) {
+		err := read("GET /api/STService/testSTReq HTTP/1.1\r\n" +
+			"X-A: " + strings.Repeat("a", 50) + "\r\n" +
+			"X-B: " + strings.Repeat("b", 50) + "\r\n\r\n")
+		assert.Equal(t, ErrHeaderTooLarge, err)
+	})
+	t.Run("header count", func(t *testing.T) {
+		input := "GET /api/STService/testSTReq HTTP/1.1\r\n" + strings.Repeat("X: 1\r\n", maxHeaderCount+1) + "\r\n"
+		_, err := readRequest(netpoll.NewReader(strings.NewReader(input)), defaultReadLimits)
+		assert.Equal(t, ErrHeaderTooLarge, err)
+	})
+	t.Run("trailer", func(t *testing.T) {
+		err := read("POST /api/STService/testSTReq HTTP/1.1\r\n" +
+			"Transfer-Encoding: chunked\r\n\r\n" +
+			"0\r\n" +
+			"X-Checksum: " + strings.Repeat("c", 200) + "\r\n\r\n")
+		assert.Equal(t, ErrHeaderTooLarge, err)
+	})
+	t.Run("within limit", func(t *testing.T) {
+		assert.NoError(t, read("GET /api/STService/testSTReq HTTP/1.1\r\nX-A: a\r\n\r\n"))
+	})
+}
@@ -0,0 +1,169 @@
+package http1
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cloudwego/netpoll"
+)
+
+// 默认的流式响应阈值：Body 超过 64KB 时切换为 chunked 输出
+const defaultChunkedThreshold = 64 * block1k
+
+// responseWriter 负责把一个 HTTP 响应写到连接上。
+//
+// Body 在阈值以内时完整缓冲，结束时以 Content-Length 一次性写出；
+// 超过阈值后先写出响应头（Transfer-Encoding: chunked），之后每积累 block8k 字节就作为一个 chunk 刷到连接上，
+// 因此大结果不会在内存中完整保留两份。HTTP/1.0 客户端不支持 chunked，此时不带长度直接写出并在结束后关闭连接。
+type responseWriter struct {
+	writer    netpoll.Writer
+	req       *request
+	threshold int
+
+	status int
+	header []string // 形如 "Key: Value" 的 Header 行，按写入顺序输出
+	buf    []byte
+
+	committed bool // 响应头是否已写出
+	chunked   bool
+}
+
+func newResponseWriter(writer netpoll.Writer, req *request, threshold int) *responseWriter {
+	if req == nil {
+		req = &request{proto: "HTTP/1.1", keepAlive: true}
+	}
+	return &responseWriter{
+		writer:    writer,
+		req:       req,
+		threshold: threshold,
+		status:    http.StatusOK,
+	}
+}
+
+// SetStatus 设置响应状态码，必须在写出 Body 之前调用
+func (rw *responseWriter) SetStatus(code int) {
+	rw.status = code
+}
+
+// AddHeader 追加一个响应 Header，必须在写出 Body 之前调用
+func (rw *responseWriter) AddHeader(key, value string) {
+	rw.header = append(rw.header, key+": "+value)
+}
+
+// Write 实现 io.Writer，供编码器增量写入 Body
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	rw.buf = append(rw.buf, p...)
+	if !rw.committed {
+		// HEAD 请求需要准确的 Content-Length，始终缓冲而不流式输出
+		if len(rw.buf) <= rw.threshold || rw.req.method == http.MethodHead {
+			return len(p), nil
+		}
+		// 超过阈值，提交响应头并进入流式输出
+		rw.chunked = rw.req.proto != "HTTP/1.0"
+		if !rw.chunked {
+			rw.req.keepAlive = false
+		}
+		if err := rw.writeHeader(-1); err != nil {
+			return 0, err
+		}
+	}
+	if len(rw.buf) >= block8k {
+		if err := rw.flushBody(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Reset 丢弃已缓冲的 Body；响应头已写出时无法重置，返回 false
+func (rw *responseWriter) Reset() bool {
+	if rw.committed {
+		return false
+	}
+	rw.buf = rw.buf[:0]
+	return true
+}
+
+// Finish 写出剩余数据并刷新到连接，结束本次响应
+func (rw *responseWriter) Finish() error {
+	if !rw.committed {
+		if err := rw.writeHeader(len(rw.buf)); err != nil {
+			return err
+		}
+		// HEAD 响应只有响应头，没有 Body
+		if rw.req.method != http.MethodHead {
+			if _, err := rw.writer.WriteBinary(rw.buf); err != nil {
+				return err
+			}
+		}
+		rw.buf = nil
+		return rw.writer.Flush()
+	}
+	if err := rw.flushBody(); err != nil {
+		return err
+	}
+	if rw.chunked {
+		// last-chunk，没有 trailer
+		if _, err := rw.writer.WriteBinary([]byte("0\r\n\r\n")); err != nil {
+			return err
+		}
+	}
+	return rw.writer.Flush()
+}
+
+// writeHeader 写出状态行和 Header；contentLength < 0 表示长度未知（chunked 或读到连接关闭）
+func (rw *responseWriter) writeHeader(contentLength int) error {
+	rw.committed = true
+	b := make([]byte, 0, 256)
+	b = append(b, "HTTP/1.1 "...)
+	b = strconv.AppendInt(b, int64(rw.status), 10)
+	b = append(b, ' ')
+	b = append(b, http.StatusText(rw.status)...)
+	b = append(b, "\r\n"...)
+	for _, h := range rw.header {
+		b = append(b, h...)
+		b = append(b, "\r\n"...)
+	}
+	switch {
+	case contentLength >= 0:
+		b = append(b, "Content-Length: "...)
+		b = strconv.AppendInt(b, int64(contentLength), 10)
+		b = append(b, "\r\n"...)
+	case rw.chunked:
+		b = append(b, "Transfer-Encoding: chunked\r\n"...)
+	}
+	// Connection 头与请求协商的结果保持一致，客户端据此决定是否复用连接
+	if rw.req.keepAlive {
+		b = append(b, "Connection: keep-alive\r\n"...)
+	} else {
+		b = append(b, "Connection: close\r\n"...)
+	}
+	// 空行分隔 header 和 body
+	b = append(b, "\r\n"...)
+	_, err := rw.writer.WriteBinary(b)
+	return err
+}
+
+// flushBody 把已缓冲的 Body 写出到连接，chunked 模式下包装为一个 chunk
+func (rw *responseWriter) flushBody() error {
+	if len(rw.buf) == 0 {
+		return nil
+	}
+	if rw.chunked {
+		size := strconv.AppendInt(make([]byte, 0, 10), int64(len(rw.buf)), 16)
+		size = append(size, "\r\n"...)
+		if _, err := rw.writer.WriteBinary(size); err != nil {
+			return err
+		}
+	}
+	if _, err := rw.writer.WriteBinary(rw.buf); err != nil {
+		return err
+	}
+	if rw.chunked {
+		if _, err := rw.writer.WriteBinary([]byte("\r\n")); err != nil {
+			return err
+		}
+	}
+	rw.buf = rw.buf[:0]
+	return rw.writer.Flush()
+}
@@ -0,0 +1,85 @@
+package http1
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/netpoll"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+)
+
+func TestResponseWriter_ContentLength(t *testing.T) {
+	var out bytes.Buffer
+	rw := newResponseWriter(netpoll.NewWriter(&out), &request{proto: "HTTP/1.1", keepAlive: true}, 16)
+	rw.AddHeader("Content-Type", "application/json")
+	_, _ = rw.Write([]byte(`{"a":1}`))
+	assert.NoError(t, rw.Finish())
+
+	assert.Equal(t, "HTTP/1.1 200 OK\r\n"+
+		"Content-Type: application/json\r\n"+
+		"Content-Length: 7\r\n"+
+		"Connection: keep-alive\r\n"+
+		"\r\n"+
+		`{"a":1}`, out.String())
+}
+
+func TestResponseWriter_Chunked(t *testing.T) {
+	var out bytes.Buffer
+	rw := newResponseWriter(netpoll.NewWriter(&out), &request{proto: "HTTP/1.1", keepAlive: true}, 4)
+	_, _ = rw.Write([]byte("0123"))
+	_, _ = rw.Write([]byte("456789"))
+	assert.False(t, rw.Reset())
+	assert.NoError(t, rw.Finish())
+
+	assert.Equal(t, "HTTP/1.1 200 OK\r\n"+
+		"Transfer-Encoding: chunked\r\n"+
+		"Connection: keep-alive\r\n"+
+		"\r\n"+
+		"a\r\n0123456789\r\n"+
+		"0\r\n\r\n", out.String())
+}
+
+func TestResponseWriter_HTTP10Stream(t *testing.T) {
+	var out bytes.Buffer
+	req := &request{proto: "HTTP/1.0", keepAlive: true}
+	rw := newResponseWriter(netpoll.NewWriter(&out), req, 4)
+	_, _ = rw.Write([]byte("0123456789"))
+	assert.NoError(t, rw.Finish())
+
+	assert.False(t, req.keepAlive)
+	assert.True(t, strings.HasSuffix(out.String(), "Connection: close\r\n\r\n0123456789"))
+}
+
+func TestJSONStreamEncoder_MatchesEncodingJSON(t *testing.T) {
+	name := "kitex"
+	resp := JsonResponse{
+		Code:    200,
+		Message: "success",
+		Data: &stability.STServiceTestSTReqResult{Success: &stability.STResponse{
+			Name: &name,
+			Mp:   map[string]string{"b": "2", "a": "<1>"},
+		}},
+	}
+	var out bytes.Buffer
+	assert.NoError(t, newJSONStreamEncoder(&out, JSONConventions{}).Encode(resp))
+
+	expected, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), out.String())
+}
+
+func TestResponseWriter_Head(t *testing.T) {
+	var out bytes.Buffer
+	rw := newResponseWriter(netpoll.NewWriter(&out), &request{method: "HEAD", proto: "HTTP/1.1", keepAlive: true}, 4)
+	_, _ = rw.Write([]byte("0123456789"))
+	assert.NoError(t, rw.Finish())
+
+	assert.Equal(t, "HTTP/1.1 200 OK\r\n"+
+		"Content-Length: 10\r\n"+
+		"Connection: keep-alive\r\n"+
+		"\r\n", out.String())
+}
@@ -0,0 +1,195 @@
+package http1
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// AnyMethod 表示路由匹配任意 HTTP 方法
+const AnyMethod = "ANY"
+
+var ErrRouteConflict = errors.New("route conflict")
+
+// Route 描述一条 REST 路由：HTTP 方法 + 路径模板 → Kitex 服务方法。
+// 路径模板与 Hertz 保持一致：`:name` 匹配单个路径段，`*name` 匹配剩余的全部路径，例如：
+//
+//	Route{HTTPMethod: "GET", Path: "/users/:id/posts", ServiceName: "UserService", MethodName: "ListPosts"}
+//
+// 捕获的路径变量会通过 `path:"id"` tag 绑定到请求结构体字段上。
+type Route struct {
+	HTTPMethod  string
+	Path        string
+	ServiceName string
+	MethodName  string
+}
+
+func (r Route) String() string {
+	return fmt.Sprintf("%s %s -> %s.%s", r.HTTPMethod, r.Path, r.ServiceName, r.MethodName)
+}
+
+// Router 是按 HTTP 方法划分的路径前缀树，每个节点对应一个路径段。
+// 匹配时静态段优先于 `:param`，`:param` 优先于 `*catchAll`，查找开销只与路径段数有关，与路由数量无关。
+// Router 在构建完成后只读，可以被多个连接并发使用。
+type Router struct {
+	trees map[string]*routeNode
+}
+
+type routeNode struct {
+	static    map[string]*routeNode
+	param     *routeNode
+	catchAll  *routeNode
+	paramName string // param / catchAll 节点的变量名
+	route     *Route
+}
+
+// NewRouter 使用给定的路由创建 Router，路由冲突时返回错误
+func NewRouter(routes ...Route) (*Router, error) {
+	r := &Router{trees: make(map[string]*routeNode)}
+	for _, route := range routes {
+		if err := r.AddRoute(route); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// AddRoute 注册一条路由
+func (r *Router) AddRoute(route Route) error {
+	if route.Path == "" || route.Path[0] != '/' {
+		return fmt.Errorf("invalid route path %q: must begin with '/'", route.Path)
+	}
+	if route.ServiceName == "" || route.MethodName == "" {
+		return fmt.Errorf("invalid route %s: service and method are required", route)
+	}
+	method := strings.ToUpper(route.HTTPMethod)
+	if method == "" {
+		method = AnyMethod
+	}
+	route.HTTPMethod = method
+
+	root, ok := r.trees[method]
+	if !ok {
+		root = &routeNode{}
+		r.trees[method] = root
+	}
+	n := root
+	segments := splitPath(route.Path)
+	for i, seg := range segments {
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			name := seg[1:]
+			if n.param == nil {
+				n.param = &routeNode{paramName: name}
+			} else if n.param.paramName != name {
+				return fmt.Errorf("%w: %s uses :%s but another route uses :%s", ErrRouteConflict, route, name, n.param.paramName)
+			}
+			n = n.param
+		case strings.HasPrefix(seg, "*"):
+			if i != len(segments)-1 {
+				return fmt.Errorf("invalid route %s: catch-all must be the last segment", route)
+			}
+			name := seg[1:]
+			if n.catchAll == nil {
+				n.catchAll = &routeNode{paramName: name}
+			} else if n.catchAll.paramName != name {
+				return fmt.Errorf("%w: %s uses *%s but another route uses *%s", ErrRouteConflict, route, name, n.catchAll.paramName)
+			}
+			n = n.catchAll
+		default:
+			if n.static == nil {
+				n.static = make(map[string]*routeNode)
+			}
+			child, ok := n.static[seg]
+			if !ok {
+				child = &routeNode{}
+				n.static[seg] = child
+			}
+			n = child
+		}
+	}
+	if n.route != nil {
+		return fmt.Errorf("%w: %s duplicates %s", ErrRouteConflict, route, n.route)
+	}
+	n.route = &route
+	return nil
+}
+
+// Match 按 HTTP 方法和路径查找路由，返回命中的路由及捕获的路径变量
+func (r *Router) Match(method, path string) (*Route, map[string]string) {
+	if r == nil {
+		return nil, nil
+	}
+	segments := splitPath(path)
+	for _, m := range []string{strings.ToUpper(method), AnyMethod} {
+		root, ok := r.trees[m]
+		if !ok {
+			continue
+		}
+		params := make(map[string]string)
+		if route := root.match(segments, params); route != nil {
+			return route, params
+		}
+	}
+	return nil, nil
+}
+
+// AllowedMethods 返回能够匹配该路径的 HTTP 方法，用于生成 405 响应的 Allow 头
+func (r *Router) AllowedMethods(path string) []string {
+	if r == nil {
+		return nil
+	}
+	segments := splitPath(path)
+	var methods []string
+	for m, root := range r.trees {
+		if m == AnyMethod {
+			continue
+		}
+		if root.match(segments, make(map[string]string)) != nil {
+			methods = append(methods, m)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func (n *routeNode) match(segments []string, params map[string]string) *Route {
+	if len(segments) == 0 {
+		return n.route
+	}
+	seg, rest := segments[0], segments[1:]
+	if child, ok := n.static[seg]; ok {
+		if route := child.match(rest, params); route != nil {
+			return route
+		}
+	}
+	if n.param != nil {
+		if route := n.param.match(rest, params); route != nil {
+			params[n.param.paramName] = seg
+			return route
+		}
+	}
+	if n.catchAll != nil && n.catchAll.route != nil {
+		params[n.catchAll.paramName] = strings.Join(segments, "/")
+		return n.catchAll.route
+	}
+	return nil
+}
+
+// splitPath 将路径拆分为非空的路径段，并对每个段做百分号解码：静态段按解码后的内容匹配，路径变量也取解码后的值。
+// 先拆分再解码，因此 %2F 属于所在的路径段而不会被当作分隔符；无法解码的段保持原样，请求路径由 resolveRoute 预先校验
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if v, err := url.PathUnescape(seg); err == nil {
+			segments[i] = v
+		}
+	}
+	return segments
+}
@@ -0,0 +1,115 @@
+package http1
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_Match(t *testing.T) {
+	r, err := NewRouter(
+		Route{HTTPMethod: "GET", Path: "/users/:id", ServiceName: "UserService", MethodName: "GetUser"},
+		Route{HTTPMethod: "GET", Path: "/users/me", ServiceName: "UserService", MethodName: "GetMe"},
+		Route{HTTPMethod: "GET", Path: "/users/:id/posts", ServiceName: "UserService", MethodName: "ListPosts"},
+		Route{HTTPMethod: "POST", Path: "/users/:id/posts", ServiceName: "UserService", MethodName: "CreatePost"},
+		Route{HTTPMethod: "GET", Path: "/static/*filepath", ServiceName: "FileService", MethodName: "Get"},
+		Route{Path: "/ping", ServiceName: "PingService", MethodName: "Ping"},
+	)
+	assert.NoError(t, err)
+
+	route, params := r.Match("GET", "/users/42")
+	assert.Equal(t, "GetUser", route.MethodName)
+	assert.Equal(t, map[string]string{"id": "42"}, params)
+
+	route, _ = r.Match("GET", "/users/me")
+	assert.Equal(t, "GetMe", route.MethodName)
+
+	route, params = r.Match("post", "/users/7/posts/")
+	assert.Equal(t, "CreatePost", route.MethodName)
+	assert.Equal(t, "7", params["id"])
+
+	route, params = r.Match("GET", "/static/css/site.css")
+	assert.Equal(t, "FileService", route.ServiceName)
+	assert.Equal(t, "css/site.css", params["filepath"])
+
+	route, _ = r.Match("DELETE", "/ping")
+	assert.Equal(t, "Ping", route.MethodName)
+
+	route, _ = r.Match("DELETE", "/users/42")
+	assert.Nil(t, route)
+	route, _ = r.Match("GET", "/users/42/comments")
+	assert.Nil(t, route)
+}
+
+func TestRouter_MatchEscaped(t *testing.T) {
+	r, err := NewRouter(
+		Route{HTTPMethod: "GET", Path: "/users/:id", ServiceName: "UserService", MethodName: "GetUser"},
+		Route{HTTPMethod: "GET", Path: "/users/me", ServiceName: "UserService", MethodName: "GetMe"},
+		Route{HTTPMethod: "GET", Path: "/static/*filepath", ServiceName: "FileService", MethodName: "Get"},
+	)
+	assert.NoError(t, err)
+
+	route, params := r.Match("GET", "/users/john%20doe")
+	assert.Equal(t, "GetUser", route.MethodName)
+	assert.Equal(t, "john doe", params["id"])
+
+	// 静态段按解码后的内容匹配，%2F 不会拆分路径段
+	route, _ = r.Match("GET", "/users/%6De")
+	assert.Equal(t, "GetMe", route.MethodName)
+	route, params = r.Match("GET", "/users/a%2Fb")
+	assert.Equal(t, "GetUser", route.MethodName)
+	assert.Equal(t, "a/b", params["id"])
+
+	_, params = r.Match("GET", "/static/my%20css/site.css")
+	assert.Equal(t, "my css/site.css", params["filepath"])
+}
+
+func TestResolveRoute_InvalidEscape(t *testing.T) {
+	h := &HTTP1Handler{bridge: &bridge{options: newOptions(nil)}}
+	h.table.Store(&routeTable{})
+	err := h.resolveRoute(&request{method: "GET", path: "/users/%zz"})
+	assert.ErrorIs(t, err, ErrInvalidPathEscape)
+	assert.Equal(t, 400, DefaultErrorMapper.MapError(context.Background(), err).Status)
+}
+
+func TestRouter_Conflict(t *testing.T) {
+	_, err := NewRouter(
+		Route{HTTPMethod: "GET", Path: "/users/:id", ServiceName: "S", MethodName: "A"},
+		Route{HTTPMethod: "GET", Path: "/users/:name/posts", ServiceName: "S", MethodName: "B"},
+	)
+	assert.ErrorIs(t, err, ErrRouteConflict)
+
+	_, err = NewRouter(
+		Route{HTTPMethod: "GET", Path: "/users", ServiceName: "S", MethodName: "A"},
+		Route{HTTPMethod: "GET", Path: "/users/", ServiceName: "S", MethodName: "B"},
+	)
+	assert.ErrorIs(t, err, ErrRouteConflict)
+}
+
+func TestRoutesFromIDL(t *testing.T) {
+	routes, err := RoutesFromIDL("../idl/stability.thrift")
+	assert.NoError(t, err)
+	assert.Equal(t, []Route{{HTTPMethod: "POST", Path: "/v1/stability/st", ServiceName: "STService", MethodName: "testSTReq"}}, routes)
+}
+
+func BenchmarkRouter_Match(b *testing.B) {
+	r, _ := NewRouter()
+	for i := 0; i < 500; i++ {
+		_ = r.AddRoute(Route{HTTPMethod: "GET", Path: fmt.Sprintf("/svc%d/items/:id/detail", i), ServiceName: "S", MethodName: "M"})
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Match("GET", "/svc499/items/42/detail")
+	}
+}
+
+func TestRouter_AllowedMethods(t *testing.T) {
+	r, _ := NewRouter(
+		Route{HTTPMethod: "GET", Path: "/users/:id", ServiceName: "S", MethodName: "Get"},
+		Route{HTTPMethod: "DELETE", Path: "/users/:id", ServiceName: "S", MethodName: "Delete"},
+	)
+	assert.Equal(t, []string{"DELETE", "GET"}, r.AllowedMethods("/users/1"))
+	assert.Empty(t, r.AllowedMethods("/posts/1"))
+}
@@ -0,0 +1,76 @@
+package http1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+
+	"github.com/BeroKiTeer/KitBridge/apachethrift"
+)
+
+// Apache Thrift THttpClient / THttpTransport 兼容：Body 是一条二进制或 compact 编码的 Thrift 消息，
+// 响应 Body 是对应的 Thrift 回复，业务错误以 TApplicationException 回复，HTTP 状态码仍为 200
+
+// errThriftOverHTTP 由 Read 返回，表示请求 Body 是 Thrift 消息，不按 JSON 调用处理
+var errThriftOverHTTP = errors.New("thrift over http")
+
+// thriftContentTypes 是 Apache Thrift 各语言 HTTP 客户端使用的 Content-Type，值表示是否固定为 compact
+var thriftContentTypes = map[string]bool{
+	"application/x-thrift":                  false,
+	"application/vnd.apache.thrift.binary":  false,
+	"application/vnd.apache.thrift.compact": true,
+}
+
+func isThriftContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	_, ok := thriftContentTypes[mediaType]
+	return ok
+}
+
+// serveThriftHTTP 用 apachethrift.Processor 处理 Body 中的 Thrift 消息，经过与其它请求相同的 Kitex endpoint
+func (h *HTTP1Handler) serveThriftHTTP(ctx context.Context, conn net.Conn, cs *connState) error {
+	body, err := h.callThriftHTTP(ctx, conn, cs.req)
+	if err != nil {
+		return h.writeError(ctx, cs, h.options.ErrorMapper.MapError(ctx, err))
+	}
+	rw := newResponseWriter(cs.writer, cs.req, h.options.ChunkedThreshold)
+	rw.AddHeader("Content-Type", headerValue(cs.req.headers, "Content-Type"))
+	if _, err := rw.Write(body); err != nil {
+		return err
+	}
+	return rw.Finish()
+}
+
+// callThriftHTTP 执行 Body 中的 Thrift 消息并返回编码后的回复，HTTP/1 与 HTTP/2 共用
+func (h *HTTP1Handler) callThriftHTTP(ctx context.Context, conn net.Conn, req *request) ([]byte, error) {
+	if req.method != http.MethodPost {
+		return nil, &MethodNotAllowedError{Method: req.method, Allow: []string{http.MethodPost}}
+	}
+	mediaType, _, _ := mime.ParseMediaType(headerValue(req.headers, "Content-Type"))
+	compact := thriftContentTypes[mediaType] || (len(req.body) > 0 && req.body[0] == 0x82)
+
+	in := thrift.NewTMemoryBufferLen(len(req.body))
+	_, _ = in.Write(req.body)
+	out := thrift.NewTMemoryBuffer()
+	var iprot, oprot thrift.TProtocol
+	if compact {
+		iprot, oprot = thrift.NewTCompactProtocol(in), thrift.NewTCompactProtocol(out)
+	} else {
+		// strictRead 为 false 时同时接受旧版客户端的 non-strict binary
+		iprot, oprot = thrift.NewTBinaryProtocol(in, false, true), thrift.NewTBinaryProtocol(out, false, true)
+	}
+
+	ctx = rpcinfo.NewCtxWithRPCInfo(ctx, h.newRPCInfo(conn))
+	processor := apachethrift.NewProcessor(h.svcInfo, h.svcSearcher, h.handlerFunc)
+	if err := processor.Process(ctx, iprot, oprot); err != nil {
+		// Body 不是完整的 Thrift 消息
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBody, err)
+	}
+	return out.Bytes(), nil
+}
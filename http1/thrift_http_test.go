@@ -0,0 +1,60 @@
+package http1
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+)
+
+func TestThriftOverHTTP(t *testing.T) {
+	addr := startServer(t)
+
+	cases := []struct {
+		name        string
+		contentType string
+		factory     thrift.TProtocolFactory
+	}{
+		{"binary", "application/x-thrift", thrift.NewTBinaryProtocolFactoryDefault()},
+		{"compact", "application/x-thrift", thrift.NewTCompactProtocolFactory()},
+		{"compact media type", "application/vnd.apache.thrift.compact", thrift.NewTCompactProtocolFactory()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			trans, err := thrift.NewTHttpClient("http://" + addr + "/thrift")
+			require.NoError(t, err)
+			trans.(*thrift.THttpClient).SetHeader("Content-Type", c.contentType)
+			cli := stability.NewSTServiceClientFactory(trans, c.factory)
+			name := "alice"
+			resp, err := cli.TestSTReq(context.Background(), &stability.STRequest{Name: &name})
+			require.NoError(t, err)
+			assert.Equal(t, "alice", resp.GetName())
+		})
+	}
+
+	t.Run("method not allowed", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "http://"+addr+"/thrift", nil)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/x-thrift")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	})
+}
+
+func TestThriftOverHTTP2(t *testing.T) {
+	addr := startH2Server(t)
+	trans, err := thrift.NewTHttpClientWithOptions("http://"+addr+"/thrift", thrift.THttpClientOptions{Client: h2cClient()})
+	require.NoError(t, err)
+	cli := stability.NewSTServiceClientFactory(trans, thrift.NewTCompactProtocolFactory())
+	name := "alice"
+	resp, err := cli.TestSTReq(context.Background(), &stability.STRequest{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", resp.GetName())
+}
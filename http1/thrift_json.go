@@ -0,0 +1,348 @@
+package http1
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+)
+
+// decodeArgs 按 Thrift IDL 字段名把 JSON body 解码到生成的 XxxArgs 结构体，类型转换遵循 conv。
+//
+// 方法只有一个 struct 参数时，Body 直接作为该参数本身（如 STRequest）；
+// 为兼容旧客户端，形如 {"req": {...}} 的包装格式仍然可以识别。
+func decodeArgs(body []byte, args interface{}, conv JSONConventions) error {
+	var raw interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	v := reflect.ValueOf(args)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("args must be a non-nil pointer, got %T", args)
+	}
+	v = v.Elem()
+	d := jsonAssigner{conv: conv}
+	if arg, name, ok := singleStructArg(v); ok && !isWrappedArg(raw, name, arg.Type()) {
+		return d.assign(arg, raw, "")
+	}
+	return d.assign(v, raw, "")
+}
+
+// singleStructArg 判断 Args 是否只有一个 struct 类型的参数，返回该参数字段及其 IDL 名称
+func singleStructArg(v reflect.Value) (reflect.Value, string, bool) {
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, "", false
+	}
+	var (
+		field reflect.Value
+		name  string
+		count int
+	)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if n, _, ok := thriftFieldName(t.Field(i)); ok {
+			field, name = v.Field(i), n
+			count++
+		}
+	}
+	if count != 1 {
+		return reflect.Value{}, "", false
+	}
+	ft := field.Type()
+	if ft.Kind() == reflect.Ptr {
+		ft = ft.Elem()
+	}
+	return field, name, ft.Kind() == reflect.Struct
+}
+
+// isWrappedArg 判断 Body 是否为 {"<参数名>": ...} 的旧包装格式：
+// 只有一个 key 且与参数名相同，同时参数 struct 本身没有同名字段
+func isWrappedArg(raw interface{}, name string, argType reflect.Type) bool {
+	obj, ok := raw.(map[string]interface{})
+	if !ok || len(obj) != 1 {
+		return false
+	}
+	inner, ok := obj[name]
+	if !ok {
+		return false
+	}
+	if _, isObj := inner.(map[string]interface{}); !isObj && inner != nil {
+		return false
+	}
+	if argType.Kind() == reflect.Ptr {
+		argType = argType.Elem()
+	}
+	for i := 0; i < argType.NumField(); i++ {
+		if n, _, ok := jsonFieldName(argType.Field(i)); ok && n == name {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonAssigner 把 json.Decoder(UseNumber) 解出的通用值写入 Thrift 生成的结构体
+type jsonAssigner struct {
+	conv JSONConventions
+}
+
+// assign 把 raw 写入 v，path 用于错误信息
+func (d jsonAssigner) assign(v reflect.Value, raw interface{}, path string) error {
+	if raw == nil {
+		// null 保持字段零值，与 optional 字段未设置一致
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.assign(v.Elem(), raw, path)
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(raw))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return typeMismatch(path, "object", raw)
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			name, _, ok := jsonFieldName(sf)
+			if !ok {
+				continue
+			}
+			fieldRaw, ok := obj[name]
+			if !ok {
+				continue
+			}
+			fieldPath := joinPath(path, name)
+			if err := d.assign(v.Field(i), fieldRaw, fieldPath); err != nil {
+				return err
+			}
+			if d.conv.UniqueSets && isSetField(sf) {
+				if _, dup := dedupeSet(v.Field(i)); dup >= 0 {
+					return fmt.Errorf("%s[%d]: duplicate element in set", fieldPath, dup)
+				}
+			}
+		}
+		return nil
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return typeMismatch(path, "object", raw)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(obj)))
+		}
+		kt, et := v.Type().Key(), v.Type().Elem()
+		for k, item := range obj {
+			kv := reflect.New(kt).Elem()
+			if err := d.assign(kv, mapKeyRaw(kt, k), joinPath(path, k)); err != nil {
+				return err
+			}
+			ev := reflect.New(et).Elem()
+			if err := d.assign(ev, item, joinPath(path, k)); err != nil {
+				return err
+			}
+			v.SetMapIndex(kv, ev)
+		}
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			s, ok := raw.(string)
+			if !ok {
+				return typeMismatch(path, "string", raw)
+			}
+			var (
+				b   []byte
+				err error
+			)
+			if d.conv.BinaryAsHex {
+				b, err = hex.DecodeString(s)
+			} else {
+				b, err = base64.StdEncoding.DecodeString(s)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %v", path, err)
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return typeMismatch(path, "array", raw)
+		}
+		sv := reflect.MakeSlice(v.Type(), len(arr), len(arr))
+		for i, item := range arr {
+			if err := d.assign(sv.Index(i), item, path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		v.Set(sv)
+		return nil
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return typeMismatch(path, "string", raw)
+		}
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return typeMismatch(path, "bool", raw)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n string
+		switch x := raw.(type) {
+		case json.Number:
+			n = x.String()
+		case string:
+			// 枚举可以传名称，i64 可以传字符串形式的数值
+			if isEnumType(v.Type()) {
+				if ev, ok, err := parseEnumName(v.Type(), x); ok {
+					if err != nil {
+						return fmt.Errorf("%s: %v", path, err)
+					}
+					v.SetInt(ev)
+					return nil
+				}
+				return fmt.Errorf("%s: enum %s is not registered, use RegisterEnum to accept names", path, v.Type())
+			}
+			if v.Kind() != reflect.Int64 {
+				return typeMismatch(path, "number", raw)
+			}
+			n = x
+		default:
+			return typeMismatch(path, "number", raw)
+		}
+		i, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: invalid integer %q", path, n)
+		}
+		if v.OverflowInt(i) {
+			return fmt.Errorf("%s: %s overflows %s", path, n, v.Type())
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return typeMismatch(path, "number", raw)
+		}
+		f, err := n.Float64()
+		if err != nil || (v.Kind() == reflect.Float32 && math.Abs(f) > math.MaxFloat32) {
+			return fmt.Errorf("%s: %s overflows %s", path, n, v.Type())
+		}
+		v.SetFloat(f)
+		return nil
+	}
+	return fmt.Errorf("%s: unsupported type %s", path, v.Type())
+}
+
+// mapKeyRaw 把 JSON 对象的 key 转为目标 key 类型可以接受的通用值
+func mapKeyRaw(kt reflect.Type, k string) interface{} {
+	switch kt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// 非数字的 key 按字符串处理，枚举 key 可以使用名称
+		if _, err := strconv.ParseInt(k, 10, 64); err != nil {
+			return k
+		}
+		return json.Number(k)
+	case reflect.Float32, reflect.Float64:
+		return json.Number(k)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(k)
+		if err != nil {
+			return k
+		}
+		return b
+	}
+	return k
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func typeMismatch(path, want string, raw interface{}) error {
+	got := "null"
+	switch raw.(type) {
+	case map[string]interface{}:
+		got = "object"
+	case []interface{}:
+		got = "array"
+	case string:
+		got = "string"
+	case bool:
+		got = "bool"
+	case json.Number:
+		got = "number"
+	}
+	if path == "" {
+		path = "body"
+	}
+	return fmt.Errorf("%s: expect %s, got %s", path, want, got)
+}
+
+// unwrapResult 从生成的 XxxResult 结构体中取出真正的返回值：
+// 声明的异常字段（ID >= 1）被设置时返回该异常，否则返回 success 字段（ID 为 0）。
+// 非 Thrift 结构体原样返回
+func unwrapResult(result interface{}) interface{} {
+	v := reflect.ValueOf(result)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return result
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return result
+	}
+	var (
+		success   reflect.Value
+		hasThrift bool
+	)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		id, ok := thriftFieldID(t.Field(i))
+		if !ok {
+			continue
+		}
+		hasThrift = true
+		fv := v.Field(i)
+		if id == 0 {
+			success = fv
+			continue
+		}
+		if !isEmptyValue(fv) {
+			return fv.Interface()
+		}
+	}
+	if !hasThrift {
+		return result
+	}
+	if !success.IsValid() || (success.Kind() == reflect.Ptr && success.IsNil()) {
+		// void 方法或返回值未设置
+		return nil
+	}
+	return success.Interface()
+}
@@ -0,0 +1,75 @@
+package http1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+)
+
+func TestDecodeArgs(t *testing.T) {
+	// 单参数方法：Body 即请求 struct 本身
+	args := stability.NewSTServiceTestSTReqArgs()
+	err := decodeArgs([]byte(`{"Name":"bob","int64":9007199254740993,"stringMap":{"k":"v"},"bin":"aGk="}`), args, JSONConventions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", args.Req.GetName())
+	assert.Equal(t, int64(9007199254740993), args.Req.GetInt64())
+	assert.Equal(t, map[string]string{"k": "v"}, args.Req.StringMap)
+	assert.Equal(t, []byte("hi"), args.Req.Bin)
+
+	// 兼容旧的 {"req": {...}} 包装格式
+	args = stability.NewSTServiceTestSTReqArgs()
+	assert.NoError(t, decodeArgs([]byte(`{"req":{"Name":"alice"}}`), args, JSONConventions{}))
+	assert.Equal(t, "alice", args.Req.GetName())
+
+	args = stability.NewSTServiceTestSTReqArgs()
+	err = decodeArgs([]byte(`{"int32":"x"}`), args, JSONConventions{})
+	assert.EqualError(t, err, "int32: expect number, got string")
+	err = decodeArgs([]byte(`{"b":300}`), args, JSONConventions{})
+	assert.EqualError(t, err, "b: 300 overflows int8")
+}
+
+func TestUnwrapResult(t *testing.T) {
+	result := stability.NewSTServiceTestSTReqResult()
+	assert.Nil(t, unwrapResult(result))
+
+	name := "bob"
+	resp := &stability.STResponse{Name: &name}
+	result.Success = resp
+	assert.Equal(t, resp, unwrapResult(result))
+
+	// 非 Thrift 结构体原样返回
+	assert.Equal(t, map[string]int{"a": 1}, unwrapResult(map[string]int{"a": 1}))
+}
+
+func TestJSONConventions(t *testing.T) {
+	RegisterEnum(stability.TestEnumFromString)
+	conv := JSONConventions{EnumAsName: true, Int64AsString: true, BinaryAsHex: true, UniqueSets: true}
+	body := `{"int64":"9007199254740993","bin":"6869","stringSet":["a","b"],"e":"SECOND"}`
+
+	args := stability.NewSTServiceTestSTReqArgs()
+	assert.NoError(t, decodeArgs([]byte(body), args, conv))
+	assert.Equal(t, int64(9007199254740993), args.Req.GetInt64())
+	assert.Equal(t, []byte("hi"), args.Req.Bin)
+	assert.Equal(t, stability.TestEnum_SECOND, args.Req.GetE())
+
+	// 请求和响应使用相同的表示
+	var out bytes.Buffer
+	assert.NoError(t, newJSONStreamEncoder(&out, conv).Encode(args.Req))
+	assert.JSONEq(t, body, out.String())
+
+	// 数值形式的枚举与 i64 同样可以接受
+	args = stability.NewSTServiceTestSTReqArgs()
+	assert.NoError(t, decodeArgs([]byte(`{"int64":1,"e":3}`), args, conv))
+	assert.Equal(t, stability.TestEnum_THIRD, args.Req.GetE())
+
+	err := decodeArgs([]byte(`{"stringSet":["a","b","a"]}`), args, conv)
+	assert.EqualError(t, err, "stringSet[2]: duplicate element in set")
+
+	out.Reset()
+	req := &stability.STRequest{StringSet: []string{"a", "a", "b"}}
+	assert.NoError(t, newJSONStreamEncoder(&out, conv).Encode(req))
+	assert.Equal(t, `{"stringSet":["a","b"]}`, out.String())
+}
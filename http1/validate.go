@@ -0,0 +1,446 @@
+package http1
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// 请求参数校验：解析 IDL 中 thrift-gen-validator 风格的 vt.* 字段注解，在调用 handler 之前检查解码后的参数，例如：
+//
+//	struct STRequest {
+//	    1: optional string Name (vt.min_size = "1", vt.max_size = "32", vt.pattern = "^[a-z]+$")
+//	    5: optional i32 int32 (vt.ge = "0", vt.lt = "100")
+//	    11: optional list<string> stringList (vt.max_size = "8", vt.elem.min_size = "1")
+//	    13: optional TestEnum e (vt.defined_only = "true")
+//	}
+//
+// 支持 const、lt、le、gt、ge、in、not_in、min_size、max_size、pattern、prefix、suffix、contains、not_contains、
+// defined_only、not_nil、skip，以及作用于元素的 elem.*、key.*、value.*；
+// required 字段与 vt.not_nil 字段缺失时同样校验失败。引用其它字段（$x）与函数（@f()）的表达式不支持，加载时忽略
+
+// FieldViolation 是一个字段的校验失败原因，Field 为以 IDL 字段名表示的路径，如 "items[0].name"
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError 表示请求参数未通过 vt.* 校验，默认映射为 400，并在响应体中列出每个字段的失败原因
+type ValidationError struct {
+	Fields []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// vtRule 是一条 vt.* 规则
+type vtRule struct {
+	name string
+	args []string
+	re   *regexp.Regexp
+}
+
+// vtRules 是作用于一个值的规则，elem / key / value 作用于 list、set 的元素与 map 的键值
+type vtRules struct {
+	rules             []vtRule
+	elem, key, value  *vtRules
+	notNil, skip      bool
+	required, defined bool
+}
+
+// fieldRules 是一个 struct 字段的规则
+type fieldRules struct {
+	name string
+	*vtRules
+}
+
+// validator 按 IDL struct 对应的生成类型索引各字段的规则，字段按 thrift 字段 ID 与生成代码对应
+type validator struct {
+	structs map[structKey]map[int]*fieldRules
+}
+
+// rulesFromAST 收集 IDL 及其 include 中所有 struct 的 vt.* 注解
+func rulesFromAST(ast *parser.Thrift) map[structKey]map[int]*fieldRules {
+	structs := make(map[structKey]map[int]*fieldRules)
+	for t := range ast.DepthFirstSearch() {
+		for _, st := range append(append(t.Structs, t.Unions...), t.Exceptions...) {
+			fields := make(map[int]*fieldRules)
+			for _, f := range st.Fields {
+				r := &vtRules{required: f.Requiredness == parser.FieldType_Required}
+				for _, anno := range f.Annotations {
+					if !strings.HasPrefix(anno.Key, "vt.") {
+						continue
+					}
+					if err := r.add(strings.TrimPrefix(anno.Key, "vt."), anno.Values); err != nil {
+						klog.Warnf("KITEX: ignore validation annotation %s on %s.%s: %v", anno.Key, st.Name, f.Name, err)
+					}
+				}
+				if r.empty() {
+					continue
+				}
+				fields[int(f.ID)] = &fieldRules{name: f.Name, vtRules: r}
+			}
+			if len(fields) > 0 {
+				structs[newStructKey(t, st)] = fields
+			}
+		}
+	}
+	return structs
+}
+
+func (r *vtRules) empty() bool {
+	return len(r.rules) == 0 && r.elem == nil && r.key == nil && r.value == nil &&
+		!r.notNil && !r.skip && !r.required && !r.defined
+}
+
+// add 解析一条去掉 "vt." 前缀的注解，elem.gt 这类嵌套规则递归解析
+func (r *vtRules) add(key string, values []string) error {
+	for _, v := range values {
+		if strings.HasPrefix(v, "$") || strings.HasPrefix(v, "@") {
+			return fmt.Errorf("unsupported expression %q", v)
+		}
+	}
+	if i := strings.IndexByte(key, '.'); i >= 0 {
+		sub := &r.elem
+		switch key[:i] {
+		case "elem":
+		case "key":
+			sub = &r.key
+		case "value":
+			sub = &r.value
+		default:
+			return fmt.Errorf("unknown rule")
+		}
+		if *sub == nil {
+			*sub = &vtRules{}
+		}
+		return (*sub).add(key[i+1:], values)
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("missing value")
+	}
+	for i, v := range values {
+		values[i] = unquote(v)
+	}
+	switch key {
+	case "not_nil":
+		r.notNil = values[0] == "true"
+	case "skip":
+		r.skip = values[0] == "true"
+	case "defined_only":
+		r.defined = values[0] == "true"
+	case "pattern":
+		re, err := regexp.Compile(values[0])
+		if err != nil {
+			return err
+		}
+		r.rules = append(r.rules, vtRule{name: key, args: values, re: re})
+	case "lt", "le", "gt", "ge":
+		if _, err := strconv.ParseFloat(values[0], 64); err != nil {
+			return err
+		}
+		r.rules = append(r.rules, vtRule{name: key, args: values})
+	case "min_size", "max_size":
+		if _, err := strconv.Atoi(values[0]); err != nil {
+			return err
+		}
+		r.rules = append(r.rules, vtRule{name: key, args: values})
+	case "const", "in", "not_in", "prefix", "suffix", "contains", "not_contains":
+		r.rules = append(r.rules, vtRule{name: key, args: values})
+	default:
+		return fmt.Errorf("unknown rule")
+	}
+	return nil
+}
+
+// unquote 去掉注解值外层的引号，如 vt.in = "'a'"
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// validate 校验解码后的 Args；单参数方法的路径以参数本身为根，与请求 Body 的结构一致
+func (vd *validator) validate(args interface{}) error {
+	if vd == nil || len(vd.structs) == 0 {
+		return nil
+	}
+	v := reflect.ValueOf(args)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	var out []FieldViolation
+	if arg, _, ok := singleStructArg(v); ok {
+		vd.walk(arg, "", &out, 0)
+	} else {
+		vd.walk(v, "", &out, 0)
+	}
+	if len(out) > 0 {
+		return &ValidationError{Fields: out}
+	}
+	return nil
+}
+
+// 校验时最多进入的嵌套层数（struct、list、map 各算一层）。解码得到的参数不会有环，
+// 这里只拒绝异常深的输入；超过时作为校验失败返回，而不是跳过更深层的规则
+const maxValidateDepth = 64
+
+// tooDeep 判断是否超过 maxValidateDepth，超过时记录一条校验失败
+func tooDeep(path string, out *[]FieldViolation, depth int) bool {
+	if depth <= maxValidateDepth {
+		return false
+	}
+	*out = append(*out, FieldViolation{Field: path, Message: fmt.Sprintf("nesting exceeds %d levels", maxValidateDepth)})
+	return true
+}
+
+// walk 进入 struct、list 与 map 查找带规则的 struct 字段
+func (vd *validator) walk(v reflect.Value, path string, out *[]FieldViolation, depth int) {
+	if tooDeep(path, out, depth) {
+		return
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		fields := lookupStruct(vd.structs, t)
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name, _, ok := thriftFieldName(sf)
+			if !ok {
+				continue
+			}
+			fpath := joinPath(path, name)
+			if id, ok := thriftFieldID(sf); ok && fields[id] != nil {
+				if fields[id].skip {
+					continue
+				}
+				vd.check(v.Field(i), fpath, fields[id].vtRules, out, depth)
+				continue
+			}
+			vd.walk(v.Field(i), fpath, out, depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			vd.walk(v.Index(i), path+"["+strconv.Itoa(i)+"]", out, depth+1)
+		}
+	case reflect.Map:
+		for _, k := range sortedKeys(v) {
+			vd.walk(v.MapIndex(k), path+"["+fmt.Sprint(k.Interface())+"]", out, depth+1)
+		}
+	}
+}
+
+// check 校验一个带规则的值，随后继续进入其中的 struct
+func (vd *validator) check(v reflect.Value, path string, r *vtRules, out *[]FieldViolation, depth int) {
+	if tooDeep(path, out, depth) {
+		return
+	}
+	fail := func(msg string) {
+		*out = append(*out, FieldViolation{Field: path, Message: msg})
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface || v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		if r.required || r.notNil {
+			fail("is required")
+		}
+		return
+	}
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if r.defined {
+		if s, ok := v.Interface().(fmt.Stringer); ok && s.String() == "<UNSET>" {
+			fail("must be a defined enum value")
+		}
+	}
+	for _, rule := range r.rules {
+		if msg := rule.check(v); msg != "" {
+			fail(msg)
+		}
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			ep := path + "[" + strconv.Itoa(i) + "]"
+			if r.elem != nil {
+				vd.check(v.Index(i), ep, r.elem, out, depth+1)
+			} else {
+				vd.walk(v.Index(i), ep, out, depth+1)
+			}
+		}
+	case reflect.Map:
+		for _, k := range sortedKeys(v) {
+			ep := path + "[" + fmt.Sprint(k.Interface()) + "]"
+			if r.key != nil {
+				vd.check(k, ep, r.key, out, depth+1)
+			}
+			if r.value != nil {
+				vd.check(v.MapIndex(k), ep, r.value, out, depth+1)
+			} else {
+				vd.walk(v.MapIndex(k), ep, out, depth+1)
+			}
+		}
+	case reflect.Struct:
+		vd.walk(v, path, out, depth+1)
+	}
+}
+
+// check 返回校验失败的原因，值的类型与规则不匹配时不校验
+func (r *vtRule) check(v reflect.Value) string {
+	switch r.name {
+	case "min_size", "max_size":
+		n, ok := valueSize(v)
+		if !ok {
+			return ""
+		}
+		limit, _ := strconv.Atoi(r.args[0])
+		if r.name == "min_size" && n < limit {
+			return fmt.Sprintf("size must be at least %d", limit)
+		}
+		if r.name == "max_size" && n > limit {
+			return fmt.Sprintf("size must be at most %d", limit)
+		}
+	case "lt", "le", "gt", "ge":
+		x, ok := valueNumber(v)
+		if !ok {
+			return ""
+		}
+		limit, _ := strconv.ParseFloat(r.args[0], 64)
+		if !compare(r.name, x, limit) {
+			return fmt.Sprintf("must be %s %s", compareText[r.name], r.args[0])
+		}
+	case "const":
+		if s, ok := valueText(v); ok && s != r.args[0] {
+			return fmt.Sprintf("must be %s", r.args[0])
+		}
+	case "in", "not_in":
+		s, ok := valueText(v)
+		if !ok {
+			return ""
+		}
+		found := false
+		for _, a := range r.args {
+			found = found || a == s
+		}
+		if r.name == "in" && !found {
+			return fmt.Sprintf("must be one of [%s]", strings.Join(r.args, ", "))
+		}
+		if r.name == "not_in" && found {
+			return fmt.Sprintf("must not be one of [%s]", strings.Join(r.args, ", "))
+		}
+	case "pattern", "prefix", "suffix", "contains", "not_contains":
+		s, ok := valueString(v)
+		if !ok {
+			return ""
+		}
+		a := r.args[0]
+		switch {
+		case r.name == "pattern" && !r.re.MatchString(s):
+			return fmt.Sprintf("must match %q", a)
+		case r.name == "prefix" && !strings.HasPrefix(s, a):
+			return fmt.Sprintf("must have prefix %q", a)
+		case r.name == "suffix" && !strings.HasSuffix(s, a):
+			return fmt.Sprintf("must have suffix %q", a)
+		case r.name == "contains" && !strings.Contains(s, a):
+			return fmt.Sprintf("must contain %q", a)
+		case r.name == "not_contains" && strings.Contains(s, a):
+			return fmt.Sprintf("must not contain %q", a)
+		}
+	}
+	return ""
+}
+
+var compareText = map[string]string{"lt": "<", "le": "<=", "gt": ">", "ge": ">="}
+
+func compare(op string, x, limit float64) bool {
+	switch op {
+	case "lt":
+		return x < limit
+	case "le":
+		return x <= limit
+	case "gt":
+		return x > limit
+	default:
+		return x >= limit
+	}
+}
+
+func valueSize(v reflect.Value) (int, bool) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+func valueNumber(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func valueString(v reflect.Value) (string, bool) {
+	switch {
+	case v.Kind() == reflect.String:
+		return v.String(), true
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return string(v.Bytes()), true
+	}
+	return "", false
+}
+
+// valueText 返回标量值与注解值比较时使用的文本
+func valueText(v reflect.Value) (string, bool) {
+	if s, ok := valueString(v); ok {
+		return s, true
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+	}
+	return "", false
+}
+
+// sortedKeys 按文本排序 map 的键，使错误列表的顺序稳定
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
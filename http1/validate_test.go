@@ -0,0 +1,141 @@
+package http1
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+)
+
+// validatedIDL 在 stability.thrift 的 STRequest 字段上加上 vt.* 注解
+func validatedIDL(t *testing.T) string {
+	raw, err := os.ReadFile("../idl/stability.thrift")
+	require.NoError(t, err)
+	idl := strings.NewReplacer(
+		"1: optional string Name", `1: optional string Name (vt.min_size = "1", vt.max_size = "8", vt.pattern = "^[a-z]+$", vt.not_nil = "true")`,
+		"5: optional i32 int32", `5: optional i32 int32 (vt.ge = "0", vt.lt = "100")`,
+		"8: optional string str", `8: optional string str (vt.in = "a", vt.in = "b")`,
+		"10: optional map<string, string> stringMap", `10: optional map<string, string> stringMap (vt.key.prefix = "k", vt.value.max_size = "2")`,
+		"11: optional list<string> stringList", `11: optional list<string> stringList (vt.max_size = "2", vt.elem.min_size = "1")`,
+		"13: optional TestEnum e", `13: optional TestEnum e (vt.defined_only = "true")`,
+		"14: optional string flagMsg", `14: optional string flagMsg (vt.const = "$Name")`,
+	).Replace(string(raw))
+	path := filepath.Join(t.TempDir(), "stability.thrift")
+	require.NoError(t, os.WriteFile(path, []byte(idl), 0o644))
+	return path
+}
+
+func TestValidator(t *testing.T) {
+	ast, err := parser.ParseFile(validatedIDL(t), nil, true)
+	require.NoError(t, err)
+	vd := &validator{structs: rulesFromAST(ast)}
+
+	name, i32, str, e := "bob", int32(5), "a", stability.TestEnum_FIRST
+	args := &stability.STServiceTestSTReqArgs{Req: &stability.STRequest{
+		Name: &name, Int32: &i32, Str: &str, E: &e,
+		StringMap:  map[string]string{"k1": "v"},
+		StringList: []string{"x"},
+	}}
+	assert.NoError(t, vd.validate(args))
+
+	bad, big, other, undefined := "Bob!", int32(100), "c", stability.TestEnum(9)
+	args.Req.Name, args.Req.Int32, args.Req.Str, args.Req.E = &bad, &big, &other, &undefined
+	args.Req.StringMap = map[string]string{"x": "long"}
+	args.Req.StringList = []string{"", "y", "z"}
+	err = vd.validate(args)
+	var vErr *ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, []FieldViolation{
+		{Field: "Name", Message: `must match "^[a-z]+$"`},
+		{Field: "int32", Message: "must be < 100"},
+		{Field: "str", Message: "must be one of [a, b]"},
+		{Field: "stringMap[x]", Message: `must have prefix "k"`},
+		{Field: "stringMap[x]", Message: "size must be at most 2"},
+		{Field: "stringList", Message: "size must be at most 2"},
+		{Field: "stringList[0]", Message: "size must be at least 1"},
+		{Field: "e", Message: "must be a defined enum value"},
+	}, vErr.Fields)
+
+	args.Req = &stability.STRequest{}
+	require.ErrorAs(t, vd.validate(args), &vErr)
+	assert.Equal(t, []FieldViolation{{Field: "Name", Message: "is required"}}, vErr.Fields)
+}
+
+func TestValidation_HTTP(t *testing.T) {
+	addr := startServer(t, WithIDLRoutes(validatedIDL(t)), WithValidation())
+	post := func(body string) (int, map[string]interface{}) {
+		return postBody(t, "http://"+addr+"/v1/stability/st", "application/json", body)
+	}
+
+	status, _ := post(`{"Name":"bob","int32":1}`)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, out := post(`{"Name":"bob","int32":-1}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "validation failed", out["message"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "int32", "message": "must be >= 0"}}, out["data"])
+}
+
+type VtLeaf struct {
+	Name string `thrift:"name,1"`
+}
+
+type VtInner struct {
+	Leaf *VtLeaf `thrift:"leaf,1"`
+}
+
+type VtMid struct {
+	Inner *VtInner `thrift:"inner,1"`
+}
+
+type VtOuter struct {
+	Mid *VtMid `thrift:"mid,1"`
+}
+
+type VtNode struct {
+	Next *VtNode `thrift:"next,1,optional"`
+}
+
+type vtArgs struct {
+	Req *VtOuter `thrift:"req,1"`
+}
+
+func TestValidator_Nested(t *testing.T) {
+	idl := `namespace go http1
+struct VtLeaf { 1: string name (vt.min_size = "1") }
+struct VtInner { 1: VtLeaf leaf }
+struct VtMid { 1: VtInner inner }
+struct VtOuter { 1: VtMid mid }
+struct VtNode { 1: optional VtNode next }
+`
+	path := filepath.Join(t.TempDir(), "nested.thrift")
+	require.NoError(t, os.WriteFile(path, []byte(idl), 0o644))
+	ast, err := parser.ParseFile(path, nil, true)
+	require.NoError(t, err)
+	vd := &validator{structs: rulesFromAST(ast)}
+
+	// 第 4 层 struct 上的规则同样生效
+	args := &vtArgs{Req: &VtOuter{Mid: &VtMid{Inner: &VtInner{Leaf: &VtLeaf{}}}}}
+	var vErr *ValidationError
+	require.ErrorAs(t, vd.validate(args), &vErr)
+	assert.Equal(t, []FieldViolation{{Field: "mid.inner.leaf.name", Message: "size must be at least 1"}}, vErr.Fields)
+	args.Req.Mid.Inner.Leaf.Name = "ok"
+	assert.NoError(t, vd.validate(args))
+
+	// 超过深度上限时返回错误而不是跳过
+	node := &VtNode{}
+	for i := 0; i < maxValidateDepth+1; i++ {
+		node = &VtNode{Next: node}
+	}
+	require.ErrorAs(t, vd.validate(&struct {
+		Req *VtNode `thrift:"req,1"`
+	}{Req: node}), &vErr)
+	assert.Contains(t, vErr.Fields[0].Message, "nesting exceeds")
+}
@@ -0,0 +1,408 @@
+package http1
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/kitex/pkg/klog"
+	"github.com/cloudwego/kitex/pkg/rpcinfo"
+	"github.com/cloudwego/netpoll"
+)
+
+// WebSocket（RFC 6455）桥接：配置的路径上接受 Upgrade: websocket，之后每个文本/二进制消息是一个 JSON 信封
+//
+//	{"id": 1, "service": "STService", "method": "testSTReq", "args": {"Name": "bob"}}
+//
+// 经过与 HTTP 请求相同的 Kitex endpoint 调用链后，以相同的 id 回复
+//
+//	{"id": 1, "status": 200, "body": {"code": 200, "message": "success", "data": {...}}}
+//
+// body 与同一调用通过 HTTP 请求得到的响应体一致，status 为对应的 HTTP 状态码。
+// 同一连接上的多个调用并发执行，回复顺序与完成顺序一致，客户端按 id 关联
+
+const (
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsCloseNormal        = 1000
+	wsCloseProtocolError = 1002
+	wsCloseTooBig        = 1009
+
+	// wsMaxInFlight 是单个连接上同时执行的调用数上限，达到上限时暂停读取新消息
+	wsMaxInFlight = 64
+	// defaultWebSocketPingInterval 是服务端发送 ping 的默认间隔，两个间隔内没有收到任何帧时关闭连接
+	defaultWebSocketPingInterval = 30 * time.Second
+)
+
+var (
+	// errWebSocketUpgrade 由 Read 返回，表示请求需要升级为 WebSocket 连接而不是作为单次调用处理
+	errWebSocketUpgrade = errors.New("websocket upgrade")
+	errWebSocketClosed  = errors.New("websocket closed")
+)
+
+// wsRequest 是客户端消息的信封
+type wsRequest struct {
+	ID      json.RawMessage `json:"id"`
+	Service string          `json:"service"`
+	Method  string          `json:"method"`
+	Args    json.RawMessage `json:"args"`
+}
+
+// wsResponse 是回复消息的信封
+type wsResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Status int             `json:"status"`
+	Body   interface{}     `json:"body"`
+}
+
+// isWebSocketUpgrade 判断请求是否是配置路径上的 WebSocket 升级请求
+func (h *HTTP1Handler) isWebSocketUpgrade(req *request) bool {
+	return h.options.WebSocketPath != "" && req.path == h.options.WebSocketPath &&
+		strings.EqualFold(headerValue(req.headers, "Upgrade"), "websocket")
+}
+
+// serveWebSocket 完成握手并在连接关闭前持续处理消息，返回后连接不再按 HTTP 使用
+func (h *HTTP1Handler) serveWebSocket(ctx context.Context, conn net.Conn, cs *connState) error {
+	upgrade := cs.req
+	upgrade.keepAlive = false
+	key := headerValue(upgrade.headers, "Sec-WebSocket-Key")
+	if upgrade.method != http.MethodGet || key == "" ||
+		headerValue(upgrade.headers, "Sec-WebSocket-Version") != "13" ||
+		!hasToken(headerValue(upgrade.headers, "Connection"), "upgrade") {
+		return h.writeError(ctx, cs, &HTTPError{
+			Status: http.StatusBadRequest, Code: http.StatusBadRequest, Message: "invalid websocket handshake",
+			Header: map[string]string{"Sec-WebSocket-Version": "13"},
+		})
+	}
+	interval := h.options.WebSocketPingInterval
+	ws := &wsConn{reader: cs.reader, writer: cs.writer, conn: conn, maxSize: h.options.MaxBodySize, idle: 2 * interval}
+	if err := ws.handshake(key); err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	go ws.keepalive(interval, stop)
+	defer close(stop)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, wsMaxInFlight)
+	defer wg.Wait()
+	for {
+		payload, err := ws.readMessage()
+		if err != nil {
+			if !errors.Is(err, errWebSocketClosed) {
+				klog.CtxDebugf(ctx, "HTTP1 websocket closed, remote=%s, error=%v", conn.RemoteAddr(), err)
+			}
+			return nil
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			h.serveWebSocketCall(ctx, ws, upgrade, payload)
+		}()
+	}
+}
+
+// serveWebSocketCall 处理一条消息：与 HTTP/2 的 JSON 调用相同，经过路由后调用 Kitex endpoint 并回复
+func (h *HTTP1Handler) serveWebSocketCall(ctx context.Context, ws *wsConn, upgrade *request, payload []byte) {
+	var env wsRequest
+	req := &request{
+		method: http.MethodPost, path: upgrade.path, rawQuery: upgrade.rawQuery, proto: upgrade.proto,
+		headers: upgrade.headers, keepAlive: true,
+	}
+	reply := func(status int, body interface{}) {
+		var buf bytes.Buffer
+		resp := wsResponse{ID: env.ID, Status: status, Body: body}
+		if err := newJSONStreamEncoder(&buf, h.options.JSON).Encode(resp); err != nil {
+			klog.CtxErrorf(ctx, "HTTP1 websocket encode response failed: %v", err)
+			buf.Reset()
+			fmt.Fprintf(&buf, `{"id":%s,"status":500,"body":{"code":500,"message":"json encode error"}}`, nullIfEmpty(env.ID))
+		}
+		if err := ws.writeFrame(wsOpText, buf.Bytes()); err != nil {
+			klog.CtxDebugf(ctx, "HTTP1 websocket write failed: %v", err)
+		}
+	}
+	fail := func(err error) {
+		e := h.options.ErrorMapper.MapError(ctx, err)
+		_, body := h.errorBody(ctx, req, e)
+		reply(e.Status, body)
+	}
+
+	ri := h.newRPCInfo(ws.conn)
+	ctx = rpcinfo.NewCtxWithRPCInfo(ctx, ri)
+	if err := json.Unmarshal(payload, &env); err != nil {
+		fail(fmt.Errorf("%w: %v", ErrInvalidBody, err))
+		return
+	}
+	if env.Service == "" || env.Method == "" {
+		fail(fmt.Errorf("%w: service and method are required", ErrInvalidBody))
+		return
+	}
+	req.serviceName, req.methodName = env.Service, env.Method
+	// 消息按 POST 调用，WithMethodVerbs 限制为其它 HTTP 方法的服务方法不能通过 WebSocket 调用
+	if err := h.checkVerb(req); err != nil {
+		fail(err)
+		return
+	}
+	if len(env.Args) > 0 && !bytes.Equal(env.Args, []byte("null")) {
+		req.body = env.Args
+	}
+	data, err := h.invoke(ctx, ri, req)
+	if err != nil {
+		fail(err)
+		return
+	}
+	reply(http.StatusOK, h.responseEncoder(req).EncodeSuccess(ctx, data))
+}
+
+func nullIfEmpty(id json.RawMessage) string {
+	if len(id) == 0 {
+		return "null"
+	}
+	return string(id)
+}
+
+// setReadIdle 限制等待下一帧的时间：netpoll 连接使用 SetReadTimeout，其它连接使用 SetReadDeadline
+func setReadIdle(conn net.Conn, d time.Duration) {
+	if c, ok := conn.(netpoll.Connection); ok {
+		_ = c.SetReadTimeout(d)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(d))
+}
+
+// wsConn 是服务端一侧的 WebSocket 连接，读取只在连接的读循环中进行，写入由并发的调用共享
+type wsConn struct {
+	reader  netpoll.Reader
+	writer  netpoll.Writer
+	conn    net.Conn
+	maxSize int
+	// idle 是等待下一帧的最长时间，任何帧（包括 pong）到达后重新计时
+	idle time.Duration
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (ws *wsConn) handshake(key string) error {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n"
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if _, err := ws.writer.WriteBinary([]byte(resp)); err != nil {
+		return err
+	}
+	return ws.writer.Flush()
+}
+
+// keepalive 每隔 interval 发送一次 ping，客户端的 pong 会刷新读超时
+func (ws *wsConn) keepalive(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := ws.writeFrame(wsOpPing, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readMessage 读取下一条完整的文本或二进制消息，期间处理分片与控制帧。
+// 收到 close 帧时回复 close 并返回 errWebSocketClosed
+func (ws *wsConn) readMessage() ([]byte, error) {
+	var (
+		message []byte
+		started bool
+	)
+	for {
+		setReadIdle(ws.conn, ws.idle)
+		fin, op, payload, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case wsOpPing:
+			if err := ws.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			ws.close(replyCloseCode(payload))
+			return nil, errWebSocketClosed
+		case wsOpText, wsOpBinary:
+			if started {
+				ws.close(wsCloseProtocolError)
+				return nil, errors.New("websocket: new message inside a fragmented message")
+			}
+			started = true
+		case wsOpContinuation:
+			if !started {
+				ws.close(wsCloseProtocolError)
+				return nil, errors.New("websocket: unexpected continuation frame")
+			}
+		default:
+			ws.close(wsCloseProtocolError)
+			return nil, fmt.Errorf("websocket: unknown opcode %d", op)
+		}
+		if len(message)+len(payload) > ws.maxSize {
+			ws.close(wsCloseTooBig)
+			return nil, ErrBodyTooLarge
+		}
+		message = append(message, payload...)
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// replyCloseCode 返回回复 close 帧时使用的状态码：没有 payload 时为 1000，状态码合法时原样回显；
+// payload 只有 1 字节、状态码不合法或 reason 不是合法的 UTF-8 时为 1002（RFC 6455 §5.5.1、§7.4）
+func replyCloseCode(payload []byte) int {
+	if len(payload) == 0 {
+		return wsCloseNormal
+	}
+	if len(payload) < 2 || !utf8.Valid(payload[2:]) {
+		return wsCloseProtocolError
+	}
+	code := int(binary.BigEndian.Uint16(payload))
+	if !validCloseCode(code) {
+		return wsCloseProtocolError
+	}
+	return code
+}
+
+// validCloseCode 判断对端能否在 close 帧中发送 code：1004–1006、1015 是保留值，
+// 1000–2999 中其余未在 IANA 注册的值与 5000 以上的值同样不合法，3000–4999 留给库与应用使用
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	}
+	return false
+}
+
+// readFrame 读取一帧并去掉掩码，客户端发送的帧必须带掩码
+func (ws *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	defer ws.reader.Release()
+	hdr, err := ws.reader.Next(2)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	fin, op = hdr[0]&0x80 != 0, hdr[0]&0x0f
+	masked, size := hdr[1]&0x80 != 0, uint64(hdr[1]&0x7f)
+	if hdr[0]&0x70 != 0 || !masked {
+		ws.close(wsCloseProtocolError)
+		return false, 0, nil, errors.New("websocket: reserved bits set or unmasked client frame")
+	}
+	switch size {
+	case 126:
+		b, err := ws.reader.Next(2)
+		if err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(b))
+	case 127:
+		b, err := ws.reader.Next(8)
+		if err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(b)
+	}
+	if op >= wsOpClose && (size > 125 || !fin) {
+		ws.close(wsCloseProtocolError)
+		return false, 0, nil, errors.New("websocket: invalid control frame")
+	}
+	if size > uint64(ws.maxSize) {
+		ws.close(wsCloseTooBig)
+		return false, 0, nil, ErrBodyTooLarge
+	}
+	mask, err := ws.reader.Next(4)
+	if err != nil {
+		return false, 0, nil, err
+	}
+	var key [4]byte
+	copy(key[:], mask)
+	// ReadBinary 返回拷贝，调用方可以在 Release 之后继续使用
+	if payload, err = ws.reader.ReadBinary(int(size)); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= key[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame 写出一个不分片、不带掩码的帧，多个调用并发回复时串行写入
+func (ws *wsConn) writeFrame(op byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closed {
+		return errWebSocketClosed
+	}
+	return ws.writeFrameLocked(op, payload)
+}
+
+func (ws *wsConn) writeFrameLocked(op byte, payload []byte) error {
+	hdr := make([]byte, 2, 10)
+	hdr[0] = 0x80 | op
+	switch n := len(payload); {
+	case n <= 125:
+		hdr[1] = byte(n)
+	case n <= 0xffff:
+		hdr[1] = 126
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(n))
+	default:
+		hdr[1] = 127
+		hdr = binary.BigEndian.AppendUint64(hdr, uint64(n))
+	}
+	if _, err := ws.writer.WriteBinary(hdr); err != nil {
+		return err
+	}
+	if _, err := ws.writer.WriteBinary(payload); err != nil {
+		return err
+	}
+	return ws.writer.Flush()
+}
+
+// close 发送 close 帧，之后的回复不再写出
+func (ws *wsConn) close(code int) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.closed {
+		return
+	}
+	_ = ws.writeFrameLocked(wsOpClose, binary.BigEndian.AppendUint16(nil, uint16(code)))
+	ws.closed = true
+}
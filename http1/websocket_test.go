@@ -0,0 +1,266 @@
+package http1
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/kitex/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
+	"github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability/stservice"
+)
+
+type echoService struct{}
+
+// TestSTReq 原样返回 Name，Name 为 "slow" 时延迟返回，用于验证并发调用
+func (*echoService) TestSTReq(ctx context.Context, req *stability.STRequest) (*stability.STResponse, error) {
+	if req.GetName() == "slow" {
+		time.Sleep(300 * time.Millisecond)
+	}
+	return &stability.STResponse{Name: req.Name}, nil
+}
+
+// wsClient 是测试用的最小 WebSocket 客户端
+type wsClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, addr, path string) *wsClient {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	_, err = io.WriteString(conn, "GET "+path+" HTTP/1.1\r\nHost: x\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	require.NoError(t, err)
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return &wsClient{conn: conn, r: r}
+}
+
+func (c *wsClient) send(t *testing.T, fin bool, op byte, payload []byte) {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = binary.BigEndian.AppendUint16(append(frame, 0x80|126), uint16(n))
+	}
+	key := []byte{1, 2, 3, 4}
+	frame = append(frame, key...)
+	for i, b := range payload {
+		frame = append(frame, b^key[i%4])
+	}
+	_, err := c.conn.Write(frame)
+	require.NoError(t, err)
+}
+
+func (c *wsClient) recv(t *testing.T) (byte, []byte) {
+	require.NoError(t, c.conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+	hdr := make([]byte, 2)
+	_, err := io.ReadFull(c.r, hdr)
+	require.NoError(t, err)
+	size := int(hdr[1] & 0x7f)
+	if size == 126 {
+		ext := make([]byte, 2)
+		_, err = io.ReadFull(c.r, ext)
+		require.NoError(t, err)
+		size = int(binary.BigEndian.Uint16(ext))
+	}
+	payload := make([]byte, size)
+	_, err = io.ReadFull(c.r, payload)
+	require.NoError(t, err)
+	return hdr[0] & 0x0f, payload
+}
+
+func (c *wsClient) recvResponse(t *testing.T) map[string]interface{} {
+	for {
+		op, payload := c.recv(t)
+		if op != wsOpText {
+			continue
+		}
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(payload, &resp))
+		return resp
+	}
+}
+
+// startServer 启动只使用 HTTP1Handler 的 Kitex 服务
+func startServer(t *testing.T, opts ...Option) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	factory := NewSvrTransHandlerFactory(opts...)
+	svr := stservice.NewServer(&echoService{}, server.WithListener(ln), server.WithTransHandlerFactory(factory))
+	go svr.Run()
+	t.Cleanup(func() { svr.Stop() })
+	time.Sleep(200 * time.Millisecond)
+	return ln.Addr().String()
+}
+
+// doRequest 发送请求并把响应体解析为 JSON 对象，返回状态码与解析结果
+func doRequest(t *testing.T, req *http.Request) (int, map[string]interface{}) {
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &out), string(raw))
+	return resp.StatusCode, out
+}
+
+// postBody 以 POST 发送 body，结果同 doRequest
+func postBody(t *testing.T, url, contentType, body string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	return doRequest(t, req)
+}
+
+// startH2Server 启动只使用 HTTP2Handler（h2c）的 Kitex 服务
+func startH2Server(t *testing.T, opts ...Option) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	factory := NewSvrTransHandlerFactory(opts...).HTTP2()
+	svr := stservice.NewServer(&echoService{}, server.WithListener(ln), server.WithTransHandlerFactory(factory))
+	go svr.Run()
+	t.Cleanup(func() { svr.Stop() })
+	time.Sleep(200 * time.Millisecond)
+	return ln.Addr().String()
+}
+
+// h2cClient 返回以 HTTP/2 prior knowledge 明文连接的客户端
+func h2cClient() *http.Client {
+	return &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+}
+
+func TestWebSocket(t *testing.T) {
+	addr := startServer(t, WithWebSocket("/ws"), WithWebSocketPingInterval(200*time.Millisecond))
+
+	t.Run("concurrent calls", func(t *testing.T) {
+		c := dialWebSocket(t, addr, "/ws")
+		c.send(t, true, wsOpText, []byte(`{"id":1,"service":"STService","method":"testSTReq","args":{"Name":"slow"}}`))
+		c.send(t, true, wsOpText, []byte(`{"id":"two","service":"STService","method":"testSTReq","args":{"Name":"fast"}}`))
+		// 慢调用尚未完成时快调用先回复
+		first, second := c.recvResponse(t), c.recvResponse(t)
+		assert.Equal(t, "two", first["id"])
+		assert.Equal(t, float64(1), second["id"])
+		assert.Equal(t, float64(200), second["status"])
+		body := second["body"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"name": "slow"}, body["data"])
+	})
+
+	t.Run("fragmented message and ping", func(t *testing.T) {
+		c := dialWebSocket(t, addr, "/ws")
+		msg := []byte(`{"id":3,"service":"STService","method":"testSTReq","args":{"Name":"frag"}}`)
+		c.send(t, false, wsOpText, msg[:10])
+		c.send(t, true, wsOpPing, []byte("hi"))
+		c.send(t, true, wsOpContinuation, msg[10:])
+		op, payload := c.recv(t)
+		assert.Equal(t, byte(wsOpPong), op)
+		assert.Equal(t, "hi", string(payload))
+		resp := c.recvResponse(t)
+		assert.Equal(t, float64(3), resp["id"])
+		assert.Equal(t, float64(200), resp["status"])
+	})
+
+	t.Run("server ping", func(t *testing.T) {
+		c := dialWebSocket(t, addr, "/ws")
+		op, _ := c.recv(t)
+		assert.Equal(t, byte(wsOpPing), op)
+	})
+
+	t.Run("error mapping", func(t *testing.T) {
+		c := dialWebSocket(t, addr, "/ws")
+		c.send(t, true, wsOpText, []byte(`{"id":4,"service":"STService","method":"nope"}`))
+		resp := c.recvResponse(t)
+		assert.Equal(t, float64(4), resp["id"])
+		assert.Equal(t, float64(404), resp["status"])
+		c.send(t, true, wsOpText, []byte(`not json`))
+		assert.Equal(t, float64(400), c.recvResponse(t)["status"])
+	})
+
+	t.Run("close", func(t *testing.T) {
+		c := dialWebSocket(t, addr, "/ws")
+		c.send(t, true, wsOpClose, binary.BigEndian.AppendUint16(nil, wsCloseNormal))
+		op, payload := c.recv(t)
+		assert.Equal(t, byte(wsOpClose), op)
+		assert.Equal(t, uint16(wsCloseNormal), binary.BigEndian.Uint16(payload))
+		_, err := c.r.ReadByte()
+		assert.True(t, errors.Is(err, io.EOF) || strings.Contains(err.Error(), "reset"), "%v", err)
+	})
+
+	t.Run("close codes", func(t *testing.T) {
+		code := func(c uint16) []byte { return binary.BigEndian.AppendUint16(nil, c) }
+		for _, tc := range []struct {
+			name    string
+			payload []byte
+			want    uint16
+		}{
+			{"empty", nil, wsCloseNormal},
+			{"echo going away", code(1001), 1001},
+			{"echo application code", append(code(4000), "bye"...), 4000},
+			{"one byte", []byte{0x03}, wsCloseProtocolError},
+			{"below 1000", code(999), wsCloseProtocolError},
+			{"reserved 1004", code(1004), wsCloseProtocolError},
+			{"no status 1005", code(1005), wsCloseProtocolError},
+			{"abnormal 1006", code(1006), wsCloseProtocolError},
+			{"tls 1015", code(1015), wsCloseProtocolError},
+			{"unassigned 2000", code(2000), wsCloseProtocolError},
+			{"above 4999", code(5000), wsCloseProtocolError},
+			{"invalid utf-8 reason", append(code(1000), 0xff), wsCloseProtocolError},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				c := dialWebSocket(t, addr, "/ws")
+				c.send(t, true, wsOpClose, tc.payload)
+				op, payload := c.recv(t)
+				for op == wsOpPing {
+					op, payload = c.recv(t)
+				}
+				assert.Equal(t, byte(wsOpClose), op)
+				require.Len(t, payload, 2)
+				assert.Equal(t, tc.want, binary.BigEndian.Uint16(payload))
+			})
+		}
+	})
+
+	t.Run("plain http still works", func(t *testing.T) {
+		resp, err := http.Post("http://"+addr+"/api/STService/testSTReq", "application/json", strings.NewReader(`{"Name":"bob"}`))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
+
+func TestWebSocket_MethodVerbs(t *testing.T) {
+	// 限制为 GET 的方法不能绕过 WithMethodVerbs 通过 WebSocket 调用
+	addr := startServer(t, WithWebSocket("/ws"), WithMethodVerbs("STService", "testSTReq", http.MethodGet))
+	c := dialWebSocket(t, addr, "/ws")
+	c.send(t, true, wsOpText, []byte(`{"id":1,"service":"STService","method":"testSTReq","args":{"Name":"bob"}}`))
+	assert.Equal(t, float64(http.StatusMethodNotAllowed), c.recvResponse(t)["status"])
+}
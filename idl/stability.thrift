@@ -51,5 +51,5 @@ struct STResponse {
 }
 
 service STService {
-    STResponse testSTReq(1: STRequest req)
+    STResponse testSTReq(1: STRequest req) (api.post = "/v1/stability/st")
 }
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"crypto/tls"
+	"net"
+	"time"
+
 	"github.com/BeroKiTeer/KitBridge/autodetect"
+	"github.com/BeroKiTeer/KitBridge/conf"
 	"github.com/BeroKiTeer/KitBridge/http1"
+	st "github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability"
 	stability "github.com/BeroKiTeer/KitBridge/kitex_gen/thrift/stability/stservice"
+	"github.com/BeroKiTeer/KitBridge/protocol"
 	"github.com/cloudwego/kitex/server"
 	"log"
 )
@@ -21,9 +28,123 @@ func main() {
 }
 
 func kitexInit() (opts []server.Option) {
-	httpHandlerFactory := &http1.HTTP1SvrTransHandlerFactory{}
+	// 注册枚举名称解析，HTTP 参数中可以直接传 FIRST / SECOND 等名称
+	http1.RegisterEnum(st.TestEnumFromString)
+	httpOpts := httpOptions()
+	httpHandlerFactory := http1.NewSvrTransHandlerFactory(httpOpts...)
+	http2HandlerFactory := httpHandlerFactory.HTTP2()
 	opts = append(opts,
-		server.WithTransHandlerFactory(autodetect.NewSvrTransHandlerFactoryWithHTTP(httpHandlerFactory)),
+		server.WithTransHandlerFactory(autodetect.NewSvrTransHandlerFactoryWithOptions(httpHandlerFactory, http2HandlerFactory, detectOptions()...)),
 	)
 	return
 }
+
+// httpOptions 根据配置文件构造 HTTP 桥接的配置项：IDL 注解中的路由、配置中声明的路由与泛化转发的下游服务
+func httpOptions() (opts []http1.Option) {
+	httpConf := conf.GetConf().HTTP
+	opts = append(opts, http1.WithIDLRoutes(httpConf.IDL...))
+	if httpConf.IDLReloadInterval != "" {
+		d, err := time.ParseDuration(httpConf.IDLReloadInterval)
+		if err != nil {
+			log.Fatalf("parse idl reload interval %s failed: %v", httpConf.IDLReloadInterval, err)
+		}
+		opts = append(opts, http1.WithIDLReload(d))
+	}
+	if httpConf.Validate {
+		opts = append(opts, http1.WithValidation())
+	}
+	for _, r := range httpConf.Routes {
+		opts = append(opts, http1.WithRoutes(http1.Route{
+			HTTPMethod:  r.Method,
+			Path:        r.Path,
+			ServiceName: r.Service,
+			MethodName:  r.RPC,
+		}))
+	}
+	opts = append(opts, http1.WithJSONConventions(http1.JSONConventions{
+		EnumAsName:    httpConf.JSON.EnumAsName,
+		Int64AsString: httpConf.JSON.Int64AsString,
+		BinaryAsHex:   httpConf.JSON.BinaryAsHex,
+		UniqueSets:    httpConf.JSON.UniqueSets,
+	}))
+	if ws := httpConf.WebSocket; ws.Path != "" {
+		opts = append(opts, http1.WithWebSocket(ws.Path))
+		if ws.PingInterval != "" {
+			d, err := time.ParseDuration(ws.PingInterval)
+			if err != nil {
+				log.Fatalf("parse websocket ping interval %s failed: %v", ws.PingInterval, err)
+			}
+			opts = append(opts, http1.WithWebSocketPingInterval(d))
+		}
+	}
+	for _, g := range httpConf.Generic {
+		opts = append(opts, http1.WithGenericServices(http1.GenericService{
+			IDL:         g.IDL,
+			IncludeDirs: g.IncludeDirs,
+			Service:     g.Service,
+			Addresses:   g.Addresses,
+		}))
+	}
+	return opts
+}
+
+// detectOptions 根据配置文件构造协议识别层的配置项：TLS 终止与 PROXY protocol
+func detectOptions() []autodetect.Option {
+	opts := []autodetect.Option{autodetect.WithTLS(tlsConfig())}
+	proxyConf := conf.GetConf().ProxyProtocol
+	if proxyConf.Enabled {
+		if len(proxyConf.TrustedCIDRs) == 0 {
+			log.Fatalf("proxy_protocol.trusted_cidrs is required when proxy_protocol is enabled")
+		}
+		opts = append(opts, autodetect.WithProxyProtocol(parseCIDRs(proxyConf.TrustedCIDRs)...))
+	}
+	for _, rule := range conf.GetConf().AccessRules {
+		r := autodetect.AccessRule{Listeners: rule.Listeners, Sources: parseCIDRs(rule.Sources)}
+		for _, name := range rule.Protocols {
+			p, ok := protocol.LookupProtocol(name)
+			if !ok {
+				log.Fatalf("unknown protocol %s in access rules", name)
+			}
+			r.Protocols = append(r.Protocols, p)
+		}
+		opts = append(opts, autodetect.WithAccessRules(r))
+	}
+	detectConf := conf.GetConf().Detection
+	if detectConf.SniffTimeout != "" {
+		d, err := time.ParseDuration(detectConf.SniffTimeout)
+		if err != nil {
+			log.Fatalf("parse sniff timeout %s failed: %v", detectConf.SniffTimeout, err)
+		}
+		opts = append(opts, autodetect.WithSniffTimeout(d))
+	}
+	action, err := autodetect.ParseUnknownAction(detectConf.UnknownAction)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return append(opts, autodetect.WithMinSniffBytes(detectConf.MinSniffBytes), autodetect.WithUnknownAction(action))
+}
+
+func parseCIDRs(cidrs []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Fatalf("parse cidr %s failed: %v", cidr, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// tlsConfig 加载配置中的证书，未配置证书时返回 nil，不终止 TLS
+func tlsConfig() *tls.Config {
+	tlsConf := conf.GetConf().TLS
+	if tlsConf.CertFile == "" {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(tlsConf.CertFile, tlsConf.KeyFile)
+	if err != nil {
+		log.Fatalf("load tls certificate failed: %v", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}
+}
@@ -3,7 +3,7 @@ package protocol
 import (
 	"bufio"
 	"net"
-	"regexp"
+	"strconv"
 )
 
 type ProtocolType int
@@ -12,29 +12,34 @@ const (
 	ProtocolUnknown ProtocolType = iota
 	ProtocolThrift
 	ProtocolHttp
+	ProtocolHttp2
+	ProtocolTLS
+	ProtocolProxy
 )
 
-var httpReg = regexp.MustCompile(`^(?:GET |POST|PUT|DELE|HEAD|OPTI|CONN|TRAC|PATC)$`)
-
-// HTTP协议方法以GET/POST等名称开头
-func isHttpProtocol(data []byte) bool {
-	return httpReg.Match(data)
+// String 返回嗅探器注册的协议名
+func (p ProtocolType) String() string {
+	if p == ProtocolUnknown {
+		return "unknown"
+	}
+	if s, ok := LookupSniffer(p); ok && s.Name != "" {
+		return s.Name
+	}
+	return "protocol(" + strconv.Itoa(int(p)) + ")"
 }
 
+// DectProtocol 按已注册的嗅探器识别连接上的协议。
+// 不带 netpoll.Reader 的连接会通过 bufio 预读，预读的数据会被消费；
+// 需要继续使用该连接时改用 autodetect.Detect，它会通过 PrependConn 重新注入已读字节。
+// 预读没有超时，客户端沉默时会一直阻塞，需要超时控制时同样改用 autodetect.Detect
 func DectProtocol(conn net.Conn) (ProtocolType, error) {
-	reader := bufio.NewReader(conn)
-	peek, err := reader.Peek(8)
-	if err != nil {
-		return ProtocolUnknown, err
+	n := MaxPeek()
+	peek, err := PeekConn(conn, n)
+	if err == ErrPeekUnsupported {
+		peek, err = bufio.NewReaderSize(conn, n).Peek(n)
 	}
-
-	if isHttpProtocol(peek) {
-		return ProtocolHttp, nil
-	}
-
-	if len(peek) < 4 {
-		return ProtocolUnknown, nil
+	if len(peek) == 0 && err != nil {
+		return ProtocolUnknown, err
 	}
-
-	return ProtocolThrift, nil
+	return Sniff(peek), nil
 }
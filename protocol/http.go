@@ -0,0 +1,29 @@
+package protocol
+
+import "bytes"
+
+// HTTP/1.x 请求行以方法名开头，取前 4 个字节即可区分
+var httpMethodPrefixes = [][]byte{
+	[]byte("GET "), []byte("POST"), []byte("PUT "), []byte("DELE"), []byte("HEAD"),
+	[]byte("OPTI"), []byte("CONN"), []byte("TRAC"), []byte("PATC"),
+}
+
+// IsHTTP1 判断数据是否以 HTTP/1.x 请求方法开头
+func IsHTTP1(peek []byte) bool {
+	for _, p := range httpMethodPrefixes {
+		if bytes.HasPrefix(peek, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	RegisterSniffer(Sniffer{
+		Protocol: ProtocolHttp,
+		Name:     "http1",
+		MinPeek:  4,
+		Priority: 100,
+		Match:    IsHTTP1,
+	})
+}
@@ -0,0 +1,25 @@
+package protocol
+
+import "bytes"
+
+// HTTP2Preface 是 HTTP/2 prior knowledge（h2c）连接的客户端前言
+const HTTP2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// 只比较前 8 个字节：完整前言有 24 字节，而较短的 Thrift/HTTP 请求不一定能一次凑够，
+// 等待更多字节会阻塞其它协议的识别
+const http2PeekSize = 8
+
+// IsHTTP2Preface 判断数据是否以 HTTP/2 连接前言开头
+func IsHTTP2Preface(peek []byte) bool {
+	return bytes.HasPrefix(peek, []byte(HTTP2Preface[:http2PeekSize]))
+}
+
+func init() {
+	RegisterSniffer(Sniffer{
+		Protocol: ProtocolHttp2,
+		Name:     "h2c",
+		MinPeek:  http2PeekSize,
+		Priority: 110,
+		Match:    IsHTTP2Preface,
+	})
+}
@@ -0,0 +1,177 @@
+package protocol
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/netpoll"
+)
+
+// PROXY protocol（HAProxy）的 v1 文本头与 v2 二进制头
+const (
+	proxyV1Prefix = "PROXY "
+	// v1 头（含 \r\n）最长 107 字节
+	proxyV1MaxLen = 107
+	proxyV2Sig    = "\r\n\r\n\x00\r\nQUIT\n"
+	// v2 固定头：12 字节签名 + 版本/命令 + 地址族/传输协议 + 2 字节地址长度
+	proxyV2HeaderLen = 16
+	proxyPeekSize    = 8
+)
+
+// ErrInvalidProxyHeader 表示 PROXY 头格式错误
+var ErrInvalidProxyHeader = errors.New("protocol: invalid PROXY protocol header")
+
+// ProxyHeader 是从 PROXY 头中解析出的真实连接地址
+type ProxyHeader struct {
+	// Version 为 1 或 2
+	Version int
+	// Source 与 Destination 是客户端与负载均衡器接收连接的地址。
+	// v1 的 UNKNOWN 与 v2 的 LOCAL 命令（如健康检查）不携带地址，此时均为 nil
+	Source      net.Addr
+	Destination net.Addr
+}
+
+// IsProxyHeader 判断数据是否以 PROXY v1 或 v2 头开头
+func IsProxyHeader(peek []byte) bool {
+	return bytes.HasPrefix(peek, []byte(proxyV1Prefix)) ||
+		(len(peek) >= proxyPeekSize && bytes.HasPrefix([]byte(proxyV2Sig), peek[:proxyPeekSize]))
+}
+
+// ReadProxyHeader 从 reader 中读取并消费 PROXY 头，之后的数据保留在 reader 中
+func ReadProxyHeader(reader netpoll.Reader) (*ProxyHeader, error) {
+	peek, err := reader.Peek(proxyPeekSize)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(peek, []byte(proxyV1Prefix)) {
+		return readProxyV1(reader)
+	}
+	if bytes.HasPrefix([]byte(proxyV2Sig), peek) {
+		return readProxyV2(reader)
+	}
+	return nil, ErrInvalidProxyHeader
+}
+
+// readProxyV1 解析 "PROXY TCP4 <src> <dst> <sport> <dport>\r\n"
+func readProxyV1(reader netpoll.Reader) (*ProxyHeader, error) {
+	var line []byte
+	for n := len(proxyV1Prefix); ; n++ {
+		if n > proxyV1MaxLen {
+			return nil, fmt.Errorf("%w: v1 header too long", ErrInvalidProxyHeader)
+		}
+		peek, err := reader.Peek(n)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasSuffix(peek, []byte("\r\n")) {
+			line = peek[:n-2]
+			break
+		}
+	}
+	text := string(line)
+	fields := strings.Fields(text)
+	if err := reader.Skip(len(line) + 2); err != nil {
+		return nil, err
+	}
+	h := &ProxyHeader{Version: 1}
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return h, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProxyHeader, text)
+	}
+	src, err := parseProxyV1Addr(fields[2], fields[4])
+	if err != nil {
+		return nil, err
+	}
+	dst, err := parseProxyV1Addr(fields[3], fields[5])
+	if err != nil {
+		return nil, err
+	}
+	h.Source, h.Destination = src, dst
+	return h, nil
+}
+
+func parseProxyV1Addr(ip, port string) (net.Addr, error) {
+	addr := net.ParseIP(ip)
+	p, err := strconv.ParseUint(port, 10, 16)
+	if addr == nil || err != nil {
+		return nil, fmt.Errorf("%w: bad address %s:%s", ErrInvalidProxyHeader, ip, port)
+	}
+	return &net.TCPAddr{IP: addr, Port: int(p)}, nil
+}
+
+// readProxyV2 解析二进制头，只使用 TCP over IPv4/IPv6 的地址，TLV 扩展被忽略
+func readProxyV2(reader netpoll.Reader) (*ProxyHeader, error) {
+	hdr, err := reader.Next(proxyV2HeaderLen)
+	if err != nil {
+		return nil, err
+	}
+	if string(hdr[:12]) != proxyV2Sig || hdr[12]>>4 != 2 {
+		return nil, fmt.Errorf("%w: bad v2 signature or version", ErrInvalidProxyHeader)
+	}
+	command, family := hdr[12]&0x0f, hdr[13]
+	body, err := reader.Next(int(binary.BigEndian.Uint16(hdr[14:])))
+	if err != nil {
+		return nil, err
+	}
+	h := &ProxyHeader{Version: 2}
+	switch command {
+	case 0x0: // LOCAL：负载均衡器自身发起的连接
+		return h, nil
+	case 0x1: // PROXY
+	default:
+		return nil, fmt.Errorf("%w: unknown v2 command %d", ErrInvalidProxyHeader, command)
+	}
+	var ipLen int
+	switch family {
+	case 0x11: // TCP over IPv4
+		ipLen = net.IPv4len
+	case 0x21: // TCP over IPv6
+		ipLen = net.IPv6len
+	default:
+		// UDP、Unix socket 等地址族不携带可用的 TCP 地址
+		return h, nil
+	}
+	if len(body) < 2*ipLen+4 {
+		return nil, fmt.Errorf("%w: v2 address block too short", ErrInvalidProxyHeader)
+	}
+	h.Source = &net.TCPAddr{
+		IP:   append(net.IP(nil), body[:ipLen]...),
+		Port: int(binary.BigEndian.Uint16(body[2*ipLen:])),
+	}
+	h.Destination = &net.TCPAddr{
+		IP:   append(net.IP(nil), body[ipLen:2*ipLen]...),
+		Port: int(binary.BigEndian.Uint16(body[2*ipLen+2:])),
+	}
+	return h, nil
+}
+
+type proxyHeaderKey struct{}
+
+// WithProxyHeader 把连接的 PROXY 头保存到 ctx，请求处理时可以通过 ProxyHeaderFromContext 取出
+func WithProxyHeader(ctx context.Context, h *ProxyHeader) context.Context {
+	return context.WithValue(ctx, proxyHeaderKey{}, h)
+}
+
+// ProxyHeaderFromContext 返回连接的 PROXY 头，连接未经过 PROXY protocol 时返回 nil
+func ProxyHeaderFromContext(ctx context.Context) *ProxyHeader {
+	h, _ := ctx.Value(proxyHeaderKey{}).(*ProxyHeader)
+	return h
+}
+
+func init() {
+	RegisterSniffer(Sniffer{
+		Protocol: ProtocolProxy,
+		Name:     "proxy",
+		MinPeek:  proxyPeekSize,
+		Priority: 130,
+		Match:    IsProxyHeader,
+	})
+}
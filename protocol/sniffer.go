@@ -0,0 +1,127 @@
+package protocol
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/netpoll"
+)
+
+// ErrPeekUnsupported 表示连接不支持在不消费数据的情况下预读
+var ErrPeekUnsupported = errors.New("protocol: connection does not support peek")
+
+// Sniffer 描述一种线协议的识别方式：根据连接开头的字节判断是否为该协议
+type Sniffer struct {
+	// Protocol 是识别出的协议类型
+	Protocol ProtocolType
+	// Name 用于日志与 ProtocolType.String()
+	Name string
+	// MinPeek 是 Match 判定所需的最少字节数
+	MinPeek int
+	// Priority 越大越先尝试，相同优先级按注册顺序
+	Priority int
+	// Match 判断预读的字节是否属于该协议，peek 的长度不小于 MinPeek
+	Match func(peek []byte) bool
+}
+
+var (
+	snifferMu sync.RWMutex
+	sniffers  []Sniffer
+)
+
+// RegisterSniffer 注册协议嗅探器，同一 Protocol 重复注册时覆盖之前的嗅探器
+func RegisterSniffer(s Sniffer) {
+	if s.Match == nil || s.MinPeek <= 0 {
+		panic("protocol: sniffer requires Match and a positive MinPeek")
+	}
+	snifferMu.Lock()
+	defer snifferMu.Unlock()
+	for i := range sniffers {
+		if sniffers[i].Protocol == s.Protocol {
+			sniffers = append(sniffers[:i], sniffers[i+1:]...)
+			break
+		}
+	}
+	sniffers = append(sniffers, s)
+	sort.SliceStable(sniffers, func(i, j int) bool {
+		return sniffers[i].Priority > sniffers[j].Priority
+	})
+}
+
+// Sniffers 按优先级从高到低返回已注册的嗅探器
+func Sniffers() []Sniffer {
+	snifferMu.RLock()
+	defer snifferMu.RUnlock()
+	return append([]Sniffer(nil), sniffers...)
+}
+
+// LookupSniffer 返回指定协议的嗅探器
+func LookupSniffer(p ProtocolType) (Sniffer, bool) {
+	snifferMu.RLock()
+	defer snifferMu.RUnlock()
+	for _, s := range sniffers {
+		if s.Protocol == p {
+			return s, true
+		}
+	}
+	return Sniffer{}, false
+}
+
+// LookupProtocol 按嗅探器名称（如 "thrift"、"http1"、"h2c"）查找协议，用于解析配置
+func LookupProtocol(name string) (ProtocolType, bool) {
+	snifferMu.RLock()
+	defer snifferMu.RUnlock()
+	for _, s := range sniffers {
+		if s.Name == name {
+			return s.Protocol, true
+		}
+	}
+	return ProtocolUnknown, false
+}
+
+// Sniff 按优先级依次尝试已注册的嗅探器，字节数不足 MinPeek 的嗅探器会被跳过
+func Sniff(peek []byte) ProtocolType {
+	for _, s := range Sniffers() {
+		if len(peek) >= s.MinPeek && s.Match(peek) {
+			return s.Protocol
+		}
+	}
+	return ProtocolUnknown
+}
+
+// MaxPeek 返回所有嗅探器中最大的 MinPeek，即完成一次完整嗅探需要预读的字节数
+func MaxPeek() int {
+	n := 0
+	for _, s := range Sniffers() {
+		if s.MinPeek > n {
+			n = s.MinPeek
+		}
+	}
+	return n
+}
+
+// MatchConn 用 s 判断连接是否为对应协议，预读的数据不会被消费
+func (s Sniffer) MatchConn(conn net.Conn) bool {
+	peek, err := PeekConn(conn, s.MinPeek)
+	return err == nil && s.Match(peek)
+}
+
+// PeekConn 预读连接开头的 n 个字节，连接需要自带 netpoll.Reader（见 ConnReader）
+func PeekConn(conn net.Conn, n int) ([]byte, error) {
+	r, ok := ConnReader(conn)
+	if !ok {
+		return nil, ErrPeekUnsupported
+	}
+	return r.Peek(n)
+}
+
+// ConnReader 返回连接自带的 netpoll.Reader：netpoll.Connection 与 Kitex gonet 传输层的连接都提供 Reader()，
+// 通过它预读不会消费数据。普通 net.Conn 返回 false，需要先用 bufio 或 PrependConn 包装
+func ConnReader(conn net.Conn) (netpoll.Reader, bool) {
+	if c, ok := conn.(interface{ Reader() netpoll.Reader }); ok {
+		return c.Reader(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,108 @@
+package protocol
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/netpoll"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniff(t *testing.T) {
+	assert.Equal(t, ProtocolHttp, Sniff([]byte("GET /api HTTP/1.1")))
+	assert.Equal(t, ProtocolHttp, Sniff([]byte("PUT /v1")))
+	assert.Equal(t, ProtocolThrift, Sniff([]byte{0x80, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x04}))
+	assert.Equal(t, ProtocolUnknown, Sniff([]byte{0x80, 0x01, 0x00, 0x01}))
+	assert.Equal(t, "http1", ProtocolHttp.String())
+}
+
+func TestRegisterSniffer_Priority(t *testing.T) {
+	const custom ProtocolType = 100
+	RegisterSniffer(Sniffer{
+		Protocol: custom,
+		Name:     "custom",
+		MinPeek:  2,
+		Priority: 200,
+		Match:    func(peek []byte) bool { return peek[0] == 'G' && peek[1] == 'E' },
+	})
+	defer func() {
+		snifferMu.Lock()
+		sniffers = sniffers[1:]
+		snifferMu.Unlock()
+	}()
+
+	assert.Equal(t, custom, Sniffers()[0].Protocol)
+	assert.Equal(t, custom, Sniff([]byte("GET /")))
+	assert.Equal(t, ProtocolHttp, Sniff([]byte("POST /")))
+}
+
+func TestClassifyThrift(t *testing.T) {
+	cases := []struct {
+		peek []byte
+		want ThriftVariant
+	}{
+		{[]byte{0x80, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x04}, ThriftBuffered},
+		{[]byte{0x00, 0x00, 0x00, 0x20, 0x80, 0x01, 0x00, 0x01}, ThriftFramed},
+		{[]byte{0x00, 0x00, 0x00, 0x20, 0x10, 0x00, 0x00, 0x00}, ThriftTTHeader},
+		{[]byte{0x00, 0x00, 0x00, 0x20, 0x0f, 0xff, 0x00, 0x00}, ThriftTHeader},
+		{[]byte{0x82, 0x21, 0x01, 0x09, 't', 'e', 's', 't'}, ThriftCompact},
+		{[]byte{0x00, 0x00, 0x00, 0x20, 0x82, 0x21, 0x01, 0x09}, ThriftFramedCompact},
+		{[]byte{0x00, 0x00, 0x00, 0x09, 't', 'e', 's', 't'}, ThriftNonStrict},
+		{[]byte{0x00, 0x00, 0x00, 0x09, 0x01, 0x02, 0x03, 0x04}, ThriftVariantUnknown},
+		{[]byte("GET / HT"), ThriftVariantUnknown},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, ClassifyThrift(c.peek), "%x", c.peek)
+	}
+}
+
+func TestSniff_HTTP2(t *testing.T) {
+	assert.Equal(t, ProtocolHttp2, Sniff([]byte(HTTP2Preface)))
+	assert.Equal(t, ProtocolHttp, Sniff([]byte("POST /api HTTP/1.1")))
+}
+
+func TestSniff_TLS(t *testing.T) {
+	hello := []byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x01, 0x00, 0x01}
+	assert.True(t, IsTLSClientHello(hello))
+	assert.Equal(t, ProtocolTLS, Sniff(hello))
+	// 非 ClientHello 的握手记录与未知版本不识别为 TLS
+	assert.False(t, IsTLSClientHello([]byte{0x16, 0x03, 0x01, 0x02, 0x00, 0x02}))
+	assert.False(t, IsTLSClientHello([]byte{0x16, 0x02, 0x01, 0x02, 0x00, 0x01}))
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	r := netpoll.NewReader(strings.NewReader("PROXY TCP4 10.1.2.3 192.168.0.1 51234 8888\r\nGET / HTTP/1.1\r\n"))
+	h, err := ReadProxyHeader(r)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, h.Version)
+	assert.Equal(t, "10.1.2.3:51234", h.Source.String())
+	assert.Equal(t, "192.168.0.1:8888", h.Destination.String())
+	rest, _ := r.Peek(3)
+	assert.Equal(t, "GET", string(rest))
+
+	v2 := []byte(proxyV2Sig)
+	v2 = append(v2, 0x21, 0x11, 0x00, 0x0c, 10, 0, 0, 7, 127, 0, 0, 1, 0x1f, 0x90, 0x22, 0xb8)
+	v2 = append(v2, 0x80, 0x01)
+	assert.Equal(t, ProtocolProxy, Sniff(v2))
+	r = netpoll.NewReader(bytes.NewReader(v2))
+	h, err = ReadProxyHeader(r)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, h.Version)
+	assert.Equal(t, "10.0.0.7:8080", h.Source.String())
+	assert.Equal(t, "127.0.0.1:8888", h.Destination.String())
+	rest, _ = r.Peek(2)
+	assert.Equal(t, []byte{0x80, 0x01}, rest)
+
+	// LOCAL 命令与 UNKNOWN 不携带地址
+	local := append([]byte(proxyV2Sig), 0x20, 0x00, 0x00, 0x00)
+	h, err = ReadProxyHeader(netpoll.NewReader(bytes.NewReader(local)))
+	assert.NoError(t, err)
+	assert.Nil(t, h.Source)
+	h, err = ReadProxyHeader(netpoll.NewReader(strings.NewReader("PROXY UNKNOWN\r\n")))
+	assert.NoError(t, err)
+	assert.Nil(t, h.Source)
+
+	_, err = ReadProxyHeader(netpoll.NewReader(strings.NewReader("PROXY TCP4 a b c d\r\n")))
+	assert.ErrorIs(t, err, ErrInvalidProxyHeader)
+}
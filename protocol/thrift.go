@@ -0,0 +1,135 @@
+package protocol
+
+import (
+	"context"
+	"encoding/binary"
+)
+
+// ThriftVariant 是 Thrift 连接使用的传输层/协议组合
+type ThriftVariant int
+
+const (
+	ThriftVariantUnknown ThriftVariant = iota
+	// ThriftBuffered 未分帧的 strict binary，以 0x80 0x01 版本号开头
+	ThriftBuffered
+	// ThriftFramed 4 字节帧长度 + strict binary
+	ThriftFramed
+	// ThriftTTHeader Kitex TTHeader，帧长度后为 0x1000 魔数
+	ThriftTTHeader
+	// ThriftTHeader Apache THeader，帧长度后为 0x0FFF 魔数
+	ThriftTHeader
+	// ThriftCompact 未分帧的 compact，以 0x82 开头
+	ThriftCompact
+	// ThriftFramedCompact 4 字节帧长度 + compact
+	ThriftFramedCompact
+	// ThriftNonStrict 旧版 Apache Thrift 客户端发送的无版本号 binary，以方法名长度开头
+	ThriftNonStrict
+)
+
+var thriftVariantNames = map[ThriftVariant]string{
+	ThriftVariantUnknown: "unknown",
+	ThriftBuffered:       "buffered",
+	ThriftFramed:         "framed",
+	ThriftTTHeader:       "ttheader",
+	ThriftTHeader:        "theader",
+	ThriftCompact:        "compact",
+	ThriftFramedCompact:  "framed_compact",
+	ThriftNonStrict:      "non_strict",
+}
+
+func (v ThriftVariant) String() string {
+	if name, ok := thriftVariantNames[v]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// KitexNative 表示该变体可以直接由 Kitex 默认的 Thrift 编解码器处理
+func (v ThriftVariant) KitexNative() bool {
+	return v == ThriftBuffered || v == ThriftFramed || v == ThriftTTHeader
+}
+
+// ThriftPeekSize 是区分各 Thrift 变体所需的字节数
+const ThriftPeekSize = 8
+
+const (
+	binaryVersion1  = 0x8001
+	compactID       = 0x82
+	compactVersion  = 1
+	ttHeaderMagic   = 0x1000
+	tHeaderMagic    = 0x0FFF
+	maxNonStrictLen = 1024
+)
+
+// ClassifyThrift 根据连接开头的字节判断 Thrift 变体，peek 不足 ThriftPeekSize 时返回 ThriftVariantUnknown
+func ClassifyThrift(peek []byte) ThriftVariant {
+	if len(peek) < ThriftPeekSize {
+		return ThriftVariantUnknown
+	}
+	switch {
+	case binary.BigEndian.Uint16(peek) == binaryVersion1:
+		return ThriftBuffered
+	case isCompactHeader(peek):
+		return ThriftCompact
+	}
+	// 以下变体的前 4 个字节为帧长度
+	switch {
+	case binary.BigEndian.Uint16(peek[4:]) == binaryVersion1:
+		return ThriftFramed
+	case binary.BigEndian.Uint16(peek[4:]) == ttHeaderMagic:
+		return ThriftTTHeader
+	case binary.BigEndian.Uint16(peek[4:]) == tHeaderMagic:
+		return ThriftTHeader
+	case isCompactHeader(peek[4:]):
+		return ThriftFramedCompact
+	}
+	// non-strict binary：前 4 个字节是方法名长度，随后是方法名
+	if n := int32(binary.BigEndian.Uint32(peek)); n > 0 && n <= maxNonStrictLen {
+		name := peek[4:]
+		if int(n) < len(name) {
+			name = name[:n]
+		}
+		for _, c := range name {
+			if !isMethodNameChar(c) {
+				return ThriftVariantUnknown
+			}
+		}
+		return ThriftNonStrict
+	}
+	return ThriftVariantUnknown
+}
+
+// compact 消息以 0x82 开头，第二个字节的低 5 位为版本号
+func isCompactHeader(b []byte) bool {
+	return b[0] == compactID && b[1]&0x1f == compactVersion
+}
+
+func isMethodNameChar(c byte) bool {
+	return c == '_' || c == '.' || c == ':' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+type thriftVariantKey struct{}
+
+// WithThriftVariant 把连接的 Thrift 变体保存到 ctx，请求处理时可以通过 ThriftVariantFromContext 取出
+func WithThriftVariant(ctx context.Context, v ThriftVariant) context.Context {
+	return context.WithValue(ctx, thriftVariantKey{}, v)
+}
+
+// ThriftVariantFromContext 返回连接的 Thrift 变体
+func ThriftVariantFromContext(ctx context.Context) ThriftVariant {
+	v, _ := ctx.Value(thriftVariantKey{}).(ThriftVariant)
+	return v
+}
+
+func init() {
+	RegisterSniffer(Sniffer{
+		Protocol: ProtocolThrift,
+		Name:     "thrift",
+		MinPeek:  ThriftPeekSize,
+		Priority: 0,
+		Match: func(peek []byte) bool {
+			return ClassifyThrift(peek) != ThriftVariantUnknown
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package protocol
+
+// TLS 记录头：ContentType(1) + Version(2) + Length(2)，握手消息的第一个字节为 HandshakeType
+const (
+	tlsRecordHandshake      = 0x16
+	tlsMajorVersion         = 0x03
+	tlsHandshakeClientHello = 0x01
+	tlsPeekSize             = 6
+)
+
+// IsTLSClientHello 判断数据是否以 TLS ClientHello 记录开头（0x16 0x03 ... 0x01）
+func IsTLSClientHello(peek []byte) bool {
+	return len(peek) >= tlsPeekSize &&
+		peek[0] == tlsRecordHandshake &&
+		peek[1] == tlsMajorVersion &&
+		peek[2] <= 0x04 &&
+		peek[5] == tlsHandshakeClientHello
+}
+
+func init() {
+	RegisterSniffer(Sniffer{
+		Protocol: ProtocolTLS,
+		Name:     "tls",
+		MinPeek:  tlsPeekSize,
+		Priority: 120,
+		Match:    IsTLSClientHello,
+	})
+}